@@ -0,0 +1,43 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// htmlCommentRE matches HTML comments, which PR templates use for
+	// instructions (that often mention the markers below).
+	htmlCommentRE = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// actionRequiredRE matches the Kubernetes `ACTION REQUIRED:` release-note
+	// marker, or an "Action required" markdown heading.
+	actionRequiredRE = regexp.MustCompile(`(?m)^[[:space:]]*(?:ACTION REQUIRED:|#+[[:space:]]*(?i:action required)[[:space:]]*$)`)
+)
+
+// BreakingMarker looks for an explicit breaking-change marker in a PR body --
+// a `BREAKING CHANGE:` footer, an `ACTION REQUIRED:` note, or an "Action
+// required" section -- returning the marker found (or the empty string if
+// there is none).  Markers inside HTML comments are ignored.
+func BreakingMarker(body string) string {
+	body = htmlCommentRE.ReplaceAllString(body, "")
+	if marker := breakingFooterRE.FindString(body); marker != "" {
+		return marker
+	}
+	return strings.TrimSpace(actionRequiredRE.FindString(body))
+}
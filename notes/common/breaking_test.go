@@ -0,0 +1,47 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("Breaking-change markers in PR bodies", func() {
+	It("should find Conventional Commits breaking-change footers", func() {
+		Expect(BreakingMarker("Switches the lock.\n\nBREAKING CHANGE: ConfigMap locks are gone")).To(Equal("BREAKING CHANGE:"))
+		Expect(BreakingMarker("Switches the lock.\n\nBREAKING-CHANGE: ConfigMap locks are gone")).To(Equal("BREAKING-CHANGE:"))
+	})
+
+	It("should find ACTION REQUIRED notes", func() {
+		Expect(BreakingMarker("```release-note\nACTION REQUIRED: use leases instead of ConfigMaps\n```")).To(Equal("ACTION REQUIRED:"))
+	})
+
+	It("should find Action required sections", func() {
+		Expect(BreakingMarker("Switches the lock.\n\n## Action Required\n\nUse leases.")).To(Equal("## Action Required"))
+	})
+
+	It("should ignore markers in HTML comments", func() {
+		Expect(BreakingMarker("<!-- if this is breaking, add\nBREAKING CHANGE: <description>\n-->\nFixes #1160")).To(BeEmpty())
+	})
+
+	It("should ignore mentions that aren't markers", func() {
+		Expect(BreakingMarker("This is not a breaking change: no action required.")).To(BeEmpty())
+	})
+})
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TitleConvention is a way of marking the type of a PR in its title.
+type TitleConvention string
+
+const (
+	// EmojiConvention marks types with an emoji (or GitHub shortcode) prefix,
+	// like `:sparkles: Add a thing`.
+	EmojiConvention TitleConvention = "emoji"
+	// ConventionalCommits marks types with a Conventional Commits header,
+	// like `feat(client): add a thing`.
+	ConventionalCommits TitleConvention = "conventional"
+	// AnyConvention accepts either of the above, preferring emoji.
+	AnyConvention TitleConvention = "any"
+)
+
+// Validate checks that this is a known convention.
+func (c TitleConvention) Validate() error {
+	switch c {
+	case EmojiConvention, ConventionalCommits, AnyConvention:
+		return nil
+	default:
+		return fmt.Errorf("unknown title convention %q (must be emoji|conventional|any)", string(c))
+	}
+}
+
+var (
+	// conventionalRE matches a Conventional Commits header: `type(scope)!: description`.
+	conventionalRE = regexp.MustCompile(`^(?P<type>[[:alpha:]]+)(?:\((?P<scope>[^()]*)\))?(?P<breaking>!)?:[[:space:]]*(?P<desc>.*)$`)
+	// breakingFooterRE matches a Conventional Commits breaking-change footer.
+	breakingFooterRE = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+)
+
+// parseConventional figures out the type of a PR from a Conventional Commits
+// header in its title, using the description from the header as the title and
+// the scope as the area.  Unknown header types are UncategorizedPR.  If the
+// header has a breaking marker, or the body has a `BREAKING CHANGE:` footer,
+// the type is BreakingPR.
+func (t *Taxonomy) parseConventional(title, body string) ParsedTitle {
+	parts := conventionalRE.FindStringSubmatch(title)
+	if parts == nil {
+		return ParsedTitle{Type: UncategorizedPR, Title: title, Subject: title}
+	}
+
+	prType, known := t.byConventional(parts[conventionalRE.SubexpIndex("type")])
+	if !known {
+		return ParsedTitle{Type: UncategorizedPR, Title: title, Subject: title}
+	}
+	desc := parts[conventionalRE.SubexpIndex("desc")]
+	res := ParsedTitle{
+		Type:    prType,
+		Prefix:  strings.TrimSuffix(title, desc),
+		Area:    strings.TrimSpace(parts[conventionalRE.SubexpIndex("scope")]),
+		Title:   strings.TrimSpace(desc),
+		Subject: strings.TrimSpace(desc),
+	}
+	if parts[conventionalRE.SubexpIndex("breaking")] != "" || breakingFooterRE.MatchString(body) {
+		res.Type = BreakingPR
+	}
+	return res
+}
+
+// byConventional looks up a type by its Conventional Commits type.
+func (t *Taxonomy) byConventional(headerType string) (PRType, bool) {
+	for i, info := range t.types {
+		for _, candidate := range info.Conventional {
+			if strings.EqualFold(candidate, headerType) {
+				return PRType(i), true
+			}
+		}
+	}
+	return UncategorizedPR, false
+}
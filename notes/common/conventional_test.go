@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("Conventional Commits title parsing", func() {
+	conventional := DefaultTaxonomy()
+	Expect(conventional.SetConvention(ConventionalCommits)).To(Succeed())
+
+	DescribeTable("header to type",
+		func(title, body string, expectedType PRType, expectedTitle string) {
+			prType, finalTitle := conventional.TypeFromTitleAndBody(title, body)
+			Expect(prType).To(Equal(expectedType))
+			Expect(finalTitle).To(Equal(expectedTitle))
+		},
+		Entry("should match feature from feat", "feat: add CreateOrPatch", "", FeaturePR, "add CreateOrPatch"),
+		Entry("should match feature from feat with a scope", "feat(client): add CreateOrPatch", "", FeaturePR, "add CreateOrPatch"),
+		Entry("should match bugfix from fix", "fix: ensure that webhook server is thread/start-safe", "", BugfixPR, "ensure that webhook server is thread/start-safe"),
+		Entry("should match bugfix from fix with a scope", "fix(webhook): ensure that webhook server is thread/start-safe", "", BugfixPR, "ensure that webhook server is thread/start-safe"),
+		Entry("should match docs from docs", "docs: fix typo", "", DocsPR, "fix typo"),
+		Entry("should match infra from chore", "chore: update Go mod version to 1.15", "", InfraPR, "update Go mod version to 1.15"),
+		Entry("should match infra from ci", "ci: run the linter", "", InfraPR, "run the linter"),
+		Entry("should match types case-insensitively", "Fix: something", "", BugfixPR, "something"),
+		Entry("should match breaking from !", "feat!: change leaderlock to leases", "", BreakingPR, "change leaderlock to leases"),
+		Entry("should match breaking from ! with a scope", "fix(manager)!: change leaderlock to leases", "", BreakingPR, "change leaderlock to leases"),
+		Entry("should match breaking from a BREAKING CHANGE footer", "feat: change leaderlock to leases", "Some details.\n\nBREAKING CHANGE: the default lock changed", BreakingPR, "change leaderlock to leases"),
+		Entry("should match breaking from a BREAKING-CHANGE footer", "refactor: change leaderlock to leases", "BREAKING-CHANGE: the default lock changed", BreakingPR, "change leaderlock to leases"),
+		Entry("should not match breaking from BREAKING CHANGE mid-line", "feat: add a thing", "This is not a BREAKING CHANGE: really", FeaturePR, "add a thing"),
+		Entry("should put unknown types as uncategorized", "nit: improve doc string", "", UncategorizedPR, "nit: improve doc string"),
+		Entry("should put paths with colons as uncategorized", "hack/setup-envtest.sh: follow-up from #1092", "", UncategorizedPR, "hack/setup-envtest.sh: follow-up from #1092"),
+		Entry("should put emoji as uncategorized", ":sparkles: add CreateOrPatch", "", UncategorizedPR, ":sparkles: add CreateOrPatch"),
+	)
+
+	It("should accept either emoji or headers when using any convention", func() {
+		either := DefaultTaxonomy()
+		Expect(either.SetConvention(AnyConvention)).To(Succeed())
+
+		prType, title := either.TypeFromTitle(":sparkles: add CreateOrPatch")
+		Expect(prType).To(Equal(FeaturePR))
+		Expect(title).To(Equal("add CreateOrPatch"))
+
+		prType, title = either.TypeFromTitle("feat: add CreateOrPatch")
+		Expect(prType).To(Equal(FeaturePR))
+		Expect(title).To(Equal("add CreateOrPatch"))
+	})
+
+	It("should ignore headers when using the emoji convention", func() {
+		prType, title := DefaultTaxonomy().TypeFromTitle("feat: add CreateOrPatch")
+		Expect(prType).To(Equal(UncategorizedPR))
+		Expect(title).To(Equal("feat: add CreateOrPatch"))
+	})
+
+	It("should reject unknown conventions", func() {
+		Expect(DefaultTaxonomy().SetConvention("semaphore")).NotTo(Succeed())
+	})
+
+	It("should load the convention and custom header types from a file", func() {
+		taxonomy, err := ParseTaxonomy([]byte(`
+convention: conventional
+types:
+- name: security
+  conventional: [sec]
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(taxonomy.Convention()).To(Equal(ConventionalCommits))
+
+		prType, title := taxonomy.TypeFromTitle("sec: redact tokens")
+		Expect(taxonomy.Info(prType).Name).To(Equal("security"))
+		Expect(title).To(Equal("redact tokens"))
+	})
+
+	It("should reject header types claimed by multiple types", func() {
+		_, err := NewTaxonomy(TypeInfo{Name: "security", Conventional: []string{"Fix"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,40 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+// PRTypeFromLabels figures out the type of a PR from its labels using the
+// current taxonomy.
+func PRTypeFromLabels(labels []string) (prType PRType, label string, found bool) {
+	return currentTaxonomy.TypeFromLabels(labels)
+}
+
+// TypeFromLabels figures out the type of a PR from its labels, returning the
+// label that decided it.  If labels indicate several types, the first one in
+// the taxonomy's order wins (so breaking beats feature beats bugfix, by
+// default).  If no labels indicate a type, found is false.
+func (t *Taxonomy) TypeFromLabels(labels []string) (prType PRType, label string, found bool) {
+	for _, candidate := range t.Types() {
+		for _, typeLabel := range t.types[candidate].Labels {
+			for _, prLabel := range labels {
+				if prLabel == typeLabel {
+					return candidate, prLabel, true
+				}
+			}
+		}
+	}
+	return UncategorizedPR, "", false
+}
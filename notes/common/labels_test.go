@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("PR types from labels", func() {
+	It("should recognize the default kind labels", func() {
+		prType, label, found := PRTypeFromLabels([]string{"lgtm", "kind/bug", "approved"})
+		Expect(found).To(BeTrue())
+		Expect(prType).To(Equal(BugfixPR))
+		Expect(label).To(Equal("kind/bug"))
+
+		prType, _, found = PRTypeFromLabels([]string{"kind/api-change"})
+		Expect(found).To(BeTrue())
+		Expect(prType).To(Equal(FeaturePR))
+	})
+
+	It("should prefer types that come first in the taxonomy's order", func() {
+		prType, label, found := PRTypeFromLabels([]string{"kind/documentation", "kind/bug", "kind/feature"})
+		Expect(found).To(BeTrue())
+		Expect(prType).To(Equal(FeaturePR))
+		Expect(label).To(Equal("kind/feature"))
+	})
+
+	It("should not find a type if no labels match", func() {
+		prType, _, found := PRTypeFromLabels([]string{"lgtm", "approved"})
+		Expect(found).To(BeFalse())
+		Expect(prType).To(Equal(UncategorizedPR))
+	})
+
+	It("should use labels from custom types", func() {
+		taxonomy, err := NewTaxonomy(TypeInfo{Name: "security", Shortcodes: []string{":lock:"}, Labels: []string{"area/security"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		prType, _, found := taxonomy.TypeFromLabels([]string{"area/security"})
+		Expect(found).To(BeTrue())
+		Expect(taxonomy.Info(prType).Name).To(Equal("security"))
+	})
+
+	It("should reject labels claimed by multiple types", func() {
+		_, err := NewTaxonomy(TypeInfo{Name: "security", Labels: []string{"kind/bug"}})
+		Expect(err).To(HaveOccurred())
+	})
+})
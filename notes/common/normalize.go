@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NB: some folks' dev environments (and some browsers, and
+// copy-paste from chat apps) like to inject extra characters around emoji --
+// variation selectors, zero-width joiners & spaces, byte-order marks, etc.
+// These are invisible, so titles that look fine to a human fail to parse.
+// Everything here exists to clean those up before looking for a prefix.
+
+// fullWidthPunct maps full-width punctuation (generally from CJK input
+// methods) that might show up in a prefix to its ASCII equivalent.
+var fullWidthPunct = map[rune]rune{
+	'：': ':',
+	'！': '!',
+	'（': '(',
+	'）': ')',
+	'［': '[',
+	'］': ']',
+}
+
+// emojiAliases maps emoji (and shortcodes) that are equivalent to (or commonly
+// mistaken for) the built-in prefixes onto those prefixes.  They're only
+// consulted if the title doesn't start with a prefix from the taxonomy.
+var emojiAliases = map[string]string{
+	// breaking
+	":rotating_light:": ":warning:",
+	string('🚨'):        string('⚠'),
+	// bugfix
+	":lady_beetle:": ":bug:",
+	string('🐞'):     string('🐛'),
+	":beetle:":      ":bug:",
+	string('🪲'):     string('🐛'),
+	// docs (:open_book: is an alternate GitHub name for :book:)
+	":open_book:": ":book:",
+	// feature
+	":sparkle:": ":sparkles:",
+	string('❇'): string('✨'),
+}
+
+// isInvisible checks if the given rune is a zero-width formatting character
+// (ZWSP, ZWJ, ZWNJ, BOM, direction marks, etc) or a variation selector.
+func isInvisible(r rune) bool {
+	return unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Variation_Selector, r)
+}
+
+// NormalizeTitle cleans up a title so that prefixes that look right to a human
+// are recognized.  It applies NFC normalization, then removes invisible
+// characters and replaces full-width punctuation in the "prefix region" (any
+// leading spaces, the first word, and the spaces after it).  It returns the
+// cleaned-up title, and a description of each change made.
+func NormalizeTitle(title string) (string, []string) {
+	var warnings []string
+
+	if normalized := norm.NFC.String(title); normalized != title {
+		warnings = append(warnings, "applied unicode NFC normalization")
+		title = normalized
+	}
+
+	var (
+		res strings.Builder
+		// seenWord indicates we've passed the start of the first word
+		seenWord bool
+		// doneWord indicates we've passed the end of the first word
+		doneWord bool
+	)
+	for i, r := range title {
+		switch {
+		case isInvisible(r):
+			warnings = append(warnings, fmt.Sprintf("removed invisible character %U from the prefix", r))
+			continue
+		case unicode.IsSpace(r):
+			doneWord = seenWord
+			res.WriteRune(' ')
+			continue
+		case doneWord:
+			// we've hit the second word, so we're out of the prefix region
+			res.WriteString(title[i:])
+			return strings.TrimSpace(res.String()), warnings
+		}
+
+		seenWord = true
+		if replacement, isFullWidth := fullWidthPunct[r]; isFullWidth {
+			warnings = append(warnings, fmt.Sprintf("replaced full-width %q with %q in the prefix", r, replacement))
+			r = replacement
+		}
+		res.WriteRune(r)
+	}
+
+	return strings.TrimSpace(res.String()), warnings
+}
+
+// aliasFor finds an equivalent emoji (or shortcode) at the start of the
+// title, returning it and the canonical prefix it stands for.
+func aliasFor(title string) (alias, canonical string) {
+	for candidate := range emojiAliases {
+		if len(candidate) > len(alias) && strings.HasPrefix(title, candidate) {
+			alias = candidate
+		}
+	}
+	return alias, emojiAliases[alias]
+}
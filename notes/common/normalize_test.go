@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("PR title normalization", func() {
+	DescribeTable("titles that look right to a human should classify correctly",
+		func(title string, expectedType PRType, expectedTitle string) {
+			prType, finalTitle := PRTypeFromTitle(title)
+			Expect(prType).To(Equal(expectedType))
+			Expect(finalTitle).To(Equal(expectedTitle))
+		},
+		// variation selectors
+		Entry("VS16 after the emoji", "⚠\uFE0F Change leaderlock", BreakingPR, "Change leaderlock"),
+		Entry("VS16 after the emoji without a space", "✨\uFE0FCreateOrPatch", FeaturePR, "CreateOrPatch"),
+		Entry("VS16 before the emoji", "\uFE0F⚠ Change leaderlock", BreakingPR, "Change leaderlock"),
+		Entry("VS16 before a shortcode", "\uFE0F:warning: Change leaderlock", BreakingPR, "Change leaderlock"),
+		Entry("VS15 (text presentation) after the emoji", "⚠\uFE0E Change leaderlock", BreakingPR, "Change leaderlock"),
+		Entry("multiple variation selectors", "🐛\uFE0F\uFE0F Fix the webhook", BugfixPR, "Fix the webhook"),
+		Entry("VS16 after the space following the emoji", "🐛 \uFE0FFix the webhook", BugfixPR, "Fix the webhook"),
+
+		// zero-width characters
+		Entry("ZWSP before the emoji", "\u200B✨ Add a thing", FeaturePR, "Add a thing"),
+		Entry("ZWSP after the emoji", "✨\u200B Add a thing", FeaturePR, "Add a thing"),
+		Entry("ZWSP before the title text", "✨ \u200BAdd a thing", FeaturePR, "Add a thing"),
+		Entry("ZWJ after the emoji", "🌱\u200D Bump deps", InfraPR, "Bump deps"),
+		Entry("ZWNJ after the emoji", "🌱\u200C Bump deps", InfraPR, "Bump deps"),
+		Entry("word joiner after the emoji", "🌱\u2060 Bump deps", InfraPR, "Bump deps"),
+		Entry("ZWSP inside a shortcode", ":spark\u200Bles: Add a thing", FeaturePR, "Add a thing"),
+		Entry("ZWJ and VS16 after the emoji", "📖\uFE0F\u200D Fix typo", DocsPR, "Fix typo"),
+
+		// byte-order marks & direction marks
+		Entry("BOM at the start", "\uFEFF:bug: Fix the webhook", BugfixPR, "Fix the webhook"),
+		Entry("BOM after the emoji", "🐛\uFEFF Fix the webhook", BugfixPR, "Fix the webhook"),
+		Entry("LTR mark at the start", "\u200E🚀 release v0.0.1", ReleasePR, "release v0.0.1"),
+		Entry("RTL mark after the shortcode", ":rocket:\u200F release v0.0.1", ReleasePR, "release v0.0.1"),
+		Entry("soft hyphen inside a shortcode", ":seed\u00ADling: Bump deps", InfraPR, "Bump deps"),
+
+		// unusual spaces
+		Entry("no-break space after the emoji", "✨\u00A0Add a thing", FeaturePR, "Add a thing"),
+		Entry("ideographic space after the shortcode", ":sparkles:\u3000Add a thing", FeaturePR, "Add a thing"),
+		Entry("no-break space before the emoji", "\u00A0✨ Add a thing", FeaturePR, "Add a thing"),
+
+		// full-width punctuation
+		Entry("full-width colons around a shortcode", "：sparkles： Add a thing", FeaturePR, "Add a thing"),
+		Entry("one full-width colon in a shortcode", ":bug： Fix the webhook", BugfixPR, "Fix the webhook"),
+
+		// equivalent emoji
+		Entry(":rotating_light: as :warning:", ":rotating_light: Change leaderlock", BreakingPR, "Change leaderlock"),
+		Entry("🚨 as ⚠", "🚨 Change leaderlock", BreakingPR, "Change leaderlock"),
+		Entry("🚨 with VS16 as ⚠", "🚨\uFE0F Change leaderlock", BreakingPR, "Change leaderlock"),
+		Entry(":open_book: as :book:", ":open_book: Fix typo", DocsPR, "Fix typo"),
+		Entry(":lady_beetle: as :bug:", ":lady_beetle: Fix the webhook", BugfixPR, "Fix the webhook"),
+		Entry("🐞 as 🐛", "🐞 Fix the webhook", BugfixPR, "Fix the webhook"),
+		Entry(":beetle: as :bug:", ":beetle: Fix the webhook", BugfixPR, "Fix the webhook"),
+		Entry("🪲 as 🐛", "🪲 Fix the webhook", BugfixPR, "Fix the webhook"),
+		Entry(":sparkle: as :sparkles:", ":sparkle: Add a thing", FeaturePR, "Add a thing"),
+		Entry("❇ as ✨", "❇\uFE0F Add a thing", FeaturePR, "Add a thing"),
+
+		// NFC
+		Entry("decomposed characters in the title", "📖 Fix re\u0301sume\u0301 typo", DocsPR, "Fix résumé typo"),
+
+		// things that should stay the same
+		Entry("invisible characters outside the prefix region", "✨ Add a\u200B thing", FeaturePR, "Add a\u200B thing"),
+		Entry("full-width colons outside the prefix region", "✨ Add：a thing", FeaturePR, "Add：a thing"),
+		Entry("emoji in the middle of the message", "this is not a \u200B✨ feature", UncategorizedPR, "this is not a \u200B✨ feature"),
+		Entry("aliases in the middle of the message", "this is not a 🚨 breaking change", UncategorizedPR, "this is not a 🚨 breaking change"),
+		Entry("only invisible characters", "\u200B\uFE0F", UncategorizedPR, ""),
+	)
+
+	DescribeTable("reporting what was changed",
+		func(title, expectedTitle string, expectedWarnings []string) {
+			normalized, warnings := NormalizeTitle(title)
+			Expect(normalized).To(Equal(expectedTitle))
+			Expect(warnings).To(Equal(expectedWarnings))
+		},
+		Entry("nothing for clean titles", "✨ Add a thing", "✨ Add a thing", nil),
+		Entry("each invisible character", "\u200B✨\uFE0F Add a thing", "✨ Add a thing", []string{
+			"removed invisible character U+200B from the prefix",
+			"removed invisible character U+FE0F from the prefix",
+		}),
+		Entry("full-width punctuation", "：sparkles： Add a thing", ":sparkles: Add a thing", []string{
+			`replaced full-width '：' with ':' in the prefix`,
+			`replaced full-width '：' with ':' in the prefix`,
+		}),
+		Entry("NFC normalization", "📖 Fix re\u0301sume\u0301", "📖 Fix résumé", []string{
+			"applied unicode NFC normalization",
+		}),
+	)
+
+	It("should report aliased emoji as a warning on the parsed title", func() {
+		Expect(ParseTitle("🐞\uFE0F Fix the webhook")).To(Equal(ParsedTitle{
+			Type: BugfixPR, Prefix: "🐞", Title: "Fix the webhook", Subject: "Fix the webhook",
+			Warnings: []string{
+				"removed invisible character U+FE0F from the prefix",
+				`treated "🐞" as "🐛"`,
+			},
+		}))
+	})
+
+	It("should report warnings for titles that normalize to nothing", func() {
+		Expect(ParseTitle("\u200B\uFE0F")).To(Equal(ParsedTitle{
+			Type: UncategorizedPR,
+			Warnings: []string{
+				"removed invisible character U+200B from the prefix",
+				"removed invisible character U+FE0F from the prefix",
+			},
+		}))
+	})
+
+	It("should prefer prefixes from the taxonomy over aliases", func() {
+		taxonomy, err := NewTaxonomy(TypeInfo{Name: "security", Emoji: "🚨"})
+		Expect(err).NotTo(HaveOccurred())
+		prType, title := taxonomy.TypeFromTitle("🚨 Redact tokens")
+		Expect(taxonomy.Info(prType).Name).To(Equal("security"))
+		Expect(title).To(Equal("Redact tokens"))
+	})
+
+	It("should not alias onto prefixes missing from the taxonomy", func() {
+		taxonomy, err := NewTaxonomy(TypeInfo{Name: "docs", Shortcodes: []string{":books:"}})
+		Expect(err).NotTo(HaveOccurred())
+		prType, _ := taxonomy.TypeFromTitle(":open_book: Fix typo")
+		Expect(prType).To(Equal(UncategorizedPR))
+	})
+
+	It("should normalize Conventional Commits headers too", func() {
+		taxonomy := DefaultTaxonomy()
+		Expect(taxonomy.SetConvention(ConventionalCommits)).To(Succeed())
+		prType, title := taxonomy.TypeFromTitle("\u200Bfeat（client）：\u00A0add a thing")
+		Expect(prType).To(Equal(FeaturePR))
+		Expect(title).To(Equal("add a thing"))
+	})
+})
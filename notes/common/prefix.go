@@ -21,47 +21,22 @@ import (
 	"strings"
 )
 
+// PRType is the kind of change a PR makes.  The values here are built in, but
+// additional ones may be defined by a Taxonomy.
 type PRType int
 
+// Emoji returns the unicode prefix for this type from the current taxonomy,
+// or the name of the type in angle brackets (like `<uncategorized>`) if it
+// has none.
 func (t PRType) Emoji() string {
-	switch t {
-	case UncategorizedPR:
-		return "<uncategorized>"
-	case BreakingPR:
-		return emojiBreaking
-	case FeaturePR:
-		return emojiFeature
-	case BugfixPR:
-		return emojiBugfix
-	case DocsPR:
-		return emojiDocs
-	case InfraPR:
-		return emojiInfra
-	case ReleasePR:
-		return emojiRelease
-	default:
-		panic(fmt.Sprintf("unrecognized PR type %v", t))
+	info := currentTaxonomy.Info(t)
+	if info.Emoji == "" {
+		return "<" + info.Name + ">"
 	}
+	return info.Emoji
 }
 func (t PRType) String() string {
-	switch t {
-	case UncategorizedPR:
-		return "uncategorized"
-	case BreakingPR:
-		return "breaking"
-	case FeaturePR:
-		return "feature"
-	case BugfixPR:
-		return "bugfix"
-	case DocsPR:
-		return "docs"
-	case InfraPR:
-		return "infra"
-	case ReleasePR:
-		return "release"
-	default:
-		panic(fmt.Sprintf("unrecognized PR type %d", int(t)))
-	}
+	return currentTaxonomy.Info(t).Name
 }
 
 const (
@@ -74,70 +49,83 @@ const (
 	ReleasePR
 )
 
-// NB(directxman12): These are constants because some folks' dev environments like
-// to inject extra combining characters into the mix (generally variation selector 16,
-// which indicates emoji presentation), so we want to check that these are *just* the
-// character without the combining parts.  Note that they're a rune, so that they
-// can *only* be one codepoint.
-const (
-	emojiFeature     = string('✨')
-	emojiBugfix      = string('🐛')
-	emojiDocs        = string('📖')
-	emojiInfra       = string('🌱')
-	emojiBreaking    = string('⚠')
-	emojiInfraLegacy = string('🏃')
-	emojiRelease     = string('🚀')
-)
-
+// PRTypeFromTitle figures out the type of a PR from its title using the
+// current taxonomy, returning the title without the prefix.  It's a shorthand
+// for ParseTitle.
 func PRTypeFromTitle(title string) (PRType, string) {
-	title = strings.TrimSpace(title)
+	parsed := ParseTitle(title)
+	return parsed.Type, parsed.Title
+}
 
-	if len(title) == 0 {
-		return UncategorizedPR, title
-	}
+// PRTypeFromTitleAndBody is like PRTypeFromTitle, but also considers the PR
+// body (e.g. for Conventional Commits breaking-change footers).
+func PRTypeFromTitleAndBody(title, body string) (PRType, string) {
+	parsed := ParseTitleAndBody(title, body)
+	return parsed.Type, parsed.Title
+}
 
-	var prType PRType
-	switch {
-	case strings.HasPrefix(title, ":sparkles:"), strings.HasPrefix(title, emojiFeature):
-		title = strings.TrimPrefix(title, ":sparkles:")
-		title = strings.TrimPrefix(title, emojiFeature)
-		prType = FeaturePR
-	case strings.HasPrefix(title, ":bug:"), strings.HasPrefix(title, emojiBugfix):
-		title = strings.TrimPrefix(title, ":bug:")
-		title = strings.TrimPrefix(title, emojiBugfix)
-		prType = BugfixPR
-	case strings.HasPrefix(title, ":book:"), strings.HasPrefix(title, emojiDocs):
-		title = strings.TrimPrefix(title, ":book:")
-		title = strings.TrimPrefix(title, emojiDocs)
-		prType = DocsPR
-	case strings.HasPrefix(title, ":seedling:"), strings.HasPrefix(title, emojiInfra):
-		title = strings.TrimPrefix(title, ":seedling:")
-		title = strings.TrimPrefix(title, emojiInfra)
-		prType = InfraPR
-	case strings.HasPrefix(title, ":warning:"), strings.HasPrefix(title, emojiBreaking):
-		title = strings.TrimPrefix(title, ":warning:")
-		title = strings.TrimPrefix(title, emojiBreaking)
-		prType = BreakingPR
-	case strings.HasPrefix(title, ":rocket:"), strings.HasPrefix(title, emojiRelease):
-		title = strings.TrimPrefix(title, ":rocket:")
-		title = strings.TrimPrefix(title, emojiRelease)
-		prType = ReleasePR
-	case strings.HasPrefix(title, ":running:"), strings.HasPrefix(title, emojiInfraLegacy):
-		// This has been deprecated in favor of :seedling:
-		title = strings.TrimPrefix(title, ":running:")
-		title = strings.TrimPrefix(title, emojiInfraLegacy)
-		prType = InfraPR
-	default:
-		return UncategorizedPR, title
-	}
+// TypeFromTitle figures out the type of a PR from the prefix of its title,
+// according to this taxonomy's convention, returning the title without the
+// prefix.  Titles without a recognized prefix are UncategorizedPR.
+func (t *Taxonomy) TypeFromTitle(title string) (PRType, string) {
+	return t.TypeFromTitleAndBody(title, "")
+}
 
-	// strip the variation selector from the title, if present
-	// (some systems sneak it in -- my guess is OSX)
-	title = strings.TrimPrefix(title, "\uFE0F")
+// TypeFromTitleAndBody is like TypeFromTitle, but also considers the PR body.
+func (t *Taxonomy) TypeFromTitleAndBody(title, body string) (PRType, string) {
+	parsed := t.Parse(title, body)
+	return parsed.Type, parsed.Title
+}
 
-	// NB(directxman12): there are a few other cases like the variation selector,
-	// but I can't seem to dig them up.  If something doesn't parse as expected,
-	// check for zero-width characters and add handling here.
+// byPrefix looks up a type by one of its (emoji or shortcode) prefixes.
+func (t *Taxonomy) byPrefix(prefix string) (PRType, bool) {
+	for i, info := range t.types {
+		if PRType(i) == UncategorizedPR {
+			continue
+		}
+		for _, candidate := range info.Prefixes() {
+			if candidate == prefix {
+				return PRType(i), true
+			}
+		}
+	}
+	return UncategorizedPR, false
+}
+
+// parseEmoji figures out the type of a PR from an emoji or shortcode prefix on
+// its (space-trimmed) title.
+func (t *Taxonomy) parseEmoji(title string) ParsedTitle {
+	// check for the longest matching prefix, in case some prefix is a prefix
+	// of another one.
+	res := ParsedTitle{Type: UncategorizedPR, Title: title, Subject: title}
+	for i, info := range t.types {
+		if PRType(i) == UncategorizedPR {
+			// this is the fallback, so don't bother matching it
+			continue
+		}
+		for _, prefix := range info.Prefixes() {
+			if len(prefix) > len(res.Prefix) && strings.HasPrefix(title, prefix) {
+				res.Type, res.Prefix = PRType(i), prefix
+			}
+		}
+	}
+	if res.Prefix == "" {
+		// fall back to equivalent emoji, if the taxonomy knows the canonical one
+		alias, canonical := aliasFor(title)
+		if alias == "" {
+			return res
+		}
+		prType, known := t.byPrefix(canonical)
+		if !known {
+			return res
+		}
+		res.Type, res.Prefix = prType, alias
+		res.Warnings = append(res.Warnings, fmt.Sprintf("treated %q as %q", alias, canonical))
+	}
+	res.Deprecated = t.Info(res.Type).isDeprecated(res.Prefix)
+	title = strings.TrimPrefix(title, res.Prefix)
 
-	return prType, strings.TrimSpace(title)
+	res.Title = strings.TrimSpace(title)
+	res.Area, res.Subject = SplitArea(res.Title)
+	return res
 }
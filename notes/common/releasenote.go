@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NoReleaseNote is the contents of a release-note block that indicates the
+// PR should be left out of the release notes entirely.
+const NoReleaseNote = "NONE"
+
+var (
+	// releaseNoteStartRE matches the opening fence of a release-note block.
+	releaseNoteStartRE = regexp.MustCompile("^[[:space:]]*```[[:space:]]*release-note[[:space:]]*$")
+	// fenceEndRE matches the closing fence of a code block.
+	fenceEndRE = regexp.MustCompile("^[[:space:]]*```[[:space:]]*$")
+)
+
+// ReleaseNoteFromBody extracts the contents of the ```release-note fenced
+// block (the Kubernetes convention) from a PR body, trimming surrounding
+// whitespace.  If there's no block, found is false.  Blocks that are
+// unterminated, empty, or repeated are errors -- a block containing just
+// NoReleaseNote (in any case) is how to say "no release note".
+func ReleaseNoteFromBody(body string) (note string, found bool, err error) {
+	var (
+		inBlock bool
+		lines   []string
+	)
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		switch {
+		case !inBlock && releaseNoteStartRE.MatchString(line):
+			if found {
+				return "", false, fmt.Errorf("multiple release-note blocks found, there should be at most one")
+			}
+			inBlock, found = true, true
+		case inBlock && fenceEndRE.MatchString(line):
+			inBlock = false
+		case inBlock:
+			lines = append(lines, line)
+		}
+	}
+
+	if inBlock {
+		return "", false, fmt.Errorf("release-note block is missing its closing fence")
+	}
+	if !found {
+		return "", false, nil
+	}
+
+	note = strings.TrimSpace(strings.Join(lines, "\n"))
+	if note == "" {
+		return "", false, fmt.Errorf("release-note block is empty (use %q to leave this PR out of the release notes)", NoReleaseNote)
+	}
+	if strings.EqualFold(note, NoReleaseNote) {
+		note = NoReleaseNote
+	}
+	return note, true, nil
+}
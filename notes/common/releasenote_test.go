@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("Release notes from PR bodies", func() {
+	It("should extract the contents of the release-note block", func() {
+		note, found, err := ReleaseNoteFromBody("This fixes a thing.\r\n\r\n```release-note\r\nThe webhook server no longer panics\r\nwhen started twice.\r\n```\r\n\r\nFixes #1234")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(note).To(Equal("The webhook server no longer panics\nwhen started twice."))
+	})
+
+	It("should ignore other code blocks", func() {
+		note, found, err := ReleaseNoteFromBody("```go\nfoo := bar\n```\n\n```release-note\nAdd Foo\n```\n\n```\nmore stuff\n```")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(note).To(Equal("Add Foo"))
+	})
+
+	It("should report that no block was found", func() {
+		_, found, err := ReleaseNoteFromBody("This fixes a thing.\n\n```go\nfoo := bar\n```")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("should canonicalize NONE", func() {
+		note, found, err := ReleaseNoteFromBody("```release-note\nnone\n```")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(note).To(Equal(NoReleaseNote))
+	})
+
+	It("should reject empty blocks", func() {
+		_, _, err := ReleaseNoteFromBody("```release-note\n\n```")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject unterminated blocks", func() {
+		_, _, err := ReleaseNoteFromBody("```release-note\nAdd Foo\n")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject multiple blocks", func() {
+		_, _, err := ReleaseNoteFromBody("```release-note\nAdd Foo\n```\n```release-note\nAdd Bar\n```")
+		Expect(err).To(HaveOccurred())
+	})
+})
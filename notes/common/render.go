@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+)
+
+// RenderStyle is a way of displaying a PRType to humans.
+type RenderStyle string
+
+const (
+	// UnicodeStyle renders types as their emoji (like ✨), for places that
+	// display emoji directly.
+	UnicodeStyle RenderStyle = "unicode"
+	// ShortcodeStyle renders types as their GitHub shortcode (like
+	// `:sparkles:`), for GitHub-flavored markdown.
+	ShortcodeStyle RenderStyle = "shortcode"
+	// TextStyle renders types as a plain text label (like `[feature]`), for
+	// places without emoji support, like plain-text emails or terminals
+	// without emoji fonts.
+	TextStyle RenderStyle = "text"
+)
+
+// Validate checks that this is a known style.
+func (s RenderStyle) Validate() error {
+	switch s {
+	case UnicodeStyle, ShortcodeStyle, TextStyle:
+		return nil
+	default:
+		return fmt.Errorf("unknown rendering style %q (must be unicode|shortcode|text)", string(s))
+	}
+}
+
+// Render displays this type in the given style, according to the current
+// taxonomy.  Types without an emoji (or shortcode) fall back to the text
+// style.
+func (t PRType) Render(style RenderStyle) string {
+	info := currentTaxonomy.Info(t)
+	switch {
+	case style == UnicodeStyle && info.Emoji != "":
+		return info.Emoji
+	case style == ShortcodeStyle && len(info.Shortcodes) > 0:
+		return info.Shortcodes[0]
+	default:
+		return "[" + info.Name + "]"
+	}
+}
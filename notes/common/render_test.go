@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("Rendering PR types", func() {
+	It("should render built-in types in each style", func() {
+		Expect(FeaturePR.Render(UnicodeStyle)).To(Equal("✨"))
+		Expect(FeaturePR.Render(ShortcodeStyle)).To(Equal(":sparkles:"))
+		Expect(FeaturePR.Render(TextStyle)).To(Equal("[feature]"))
+	})
+
+	It("should render uncategorized PRs like any other type", func() {
+		Expect(UncategorizedPR.Render(UnicodeStyle)).To(Equal("[uncategorized]"))
+		Expect(UncategorizedPR.Render(ShortcodeStyle)).To(Equal(":question:"))
+		Expect(UncategorizedPR.Emoji()).To(Equal("<uncategorized>"))
+	})
+
+	It("should not treat the uncategorized shortcode as a prefix", func() {
+		prType, title := PRTypeFromTitle(":question: Fix the thing")
+		Expect(prType).To(Equal(UncategorizedPR))
+		Expect(title).To(Equal(":question: Fix the thing"))
+	})
+
+	Context("with types missing an emoji or shortcode", func() {
+		AfterEach(func() {
+			SetTaxonomy(nil)
+		})
+
+		It("should fall back to the text style", func() {
+			taxonomy, err := NewTaxonomy(
+				TypeInfo{Name: "security", Shortcodes: []string{":lock:"}},
+				TypeInfo{Name: "deprecation", Emoji: "🗑"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			SetTaxonomy(taxonomy)
+
+			security, _ := taxonomy.ByName("security")
+			Expect(security.Render(UnicodeStyle)).To(Equal("[security]"))
+			Expect(security.Render(ShortcodeStyle)).To(Equal(":lock:"))
+
+			deprecation, _ := taxonomy.ByName("deprecation")
+			Expect(deprecation.Render(UnicodeStyle)).To(Equal("🗑"))
+			Expect(deprecation.Render(ShortcodeStyle)).To(Equal("[deprecation]"))
+		})
+	})
+
+	It("should reject unknown styles", func() {
+		Expect(RenderStyle("fancy").Validate()).NotTo(Succeed())
+		Expect(TextStyle.Validate()).To(Succeed())
+	})
+})
@@ -0,0 +1,223 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// NB: this is deliberately dumb -- it's meant to give folks a
+// nudge in the right direction, not to replace actually picking a type.  Hints
+// refer to types by name, so they keep working with custom taxonomies (and
+// are skipped if the taxonomy has no type with that name).
+
+// Suggestion is a guess at the type of a PR whose title has no prefix.
+type Suggestion struct {
+	// Type is the suggested type.
+	Type PRType
+	// Confidence is how sure we are about the suggestion, from 0 to 1.
+	Confidence float64
+	// Title is the original title with the suggested type's prefix.
+	Title string
+	// Reasons lists the hints that led to the suggestion.
+	Reasons []string
+}
+
+// typeHint is a hint towards a type, by name, with a weight.
+type typeHint struct {
+	typeName string
+	weight   int
+}
+
+var (
+	// verbHints are hints from the first word of the title, which is
+	// generally a verb describing the change.
+	verbHints = map[string]typeHint{
+		"fix":       {"bugfix", 3},
+		"fixes":     {"bugfix", 3},
+		"fixed":     {"bugfix", 3},
+		"fixing":    {"bugfix", 3},
+		"correct":   {"bugfix", 2},
+		"resolve":   {"bugfix", 2},
+		"handle":    {"bugfix", 1},
+		"prevent":   {"bugfix", 2},
+		"add":       {"feature", 3},
+		"adds":      {"feature", 3},
+		"added":     {"feature", 3},
+		"adding":    {"feature", 3},
+		"implement": {"feature", 3},
+		"introduce": {"feature", 3},
+		"support":   {"feature", 2},
+		"allow":     {"feature", 2},
+		"enable":    {"feature", 2},
+		"expose":    {"feature", 2},
+		"document":  {"docs", 3},
+		"bump":      {"infra", 3},
+		"upgrade":   {"infra", 2},
+		"update":    {"infra", 1},
+		"refactor":  {"infra", 3},
+		"cleanup":   {"infra", 3},
+		"clean":     {"infra", 2},
+		"test":      {"infra", 2},
+		"release":   {"release", 3},
+		"remove":    {"breaking", 1},
+		"drop":      {"breaking", 2},
+		"rename":    {"breaking", 1},
+	}
+	// keywordHints are hints from words anywhere in the title.
+	keywordHints = map[string]typeHint{
+		"bug":           {"bugfix", 2},
+		"panic":         {"bugfix", 2},
+		"crash":         {"bugfix", 2},
+		"leak":          {"bugfix", 2},
+		"race":          {"bugfix", 1},
+		"nil":           {"bugfix", 1},
+		"regression":    {"bugfix", 2},
+		"typo":          {"docs", 3},
+		"typos":         {"docs", 3},
+		"docs":          {"docs", 2},
+		"doc":           {"docs", 2},
+		"documentation": {"docs", 2},
+		"readme":        {"docs", 2},
+		"godoc":         {"docs", 2},
+		"comment":       {"docs", 1},
+		"comments":      {"docs", 1},
+		"book":          {"docs", 1},
+		"dependencies":  {"infra", 2},
+		"deps":          {"infra", 2},
+		"ci":            {"infra", 2},
+		"lint":          {"infra", 2},
+		"linter":        {"infra", 2},
+		"tests":         {"infra", 1},
+		"e2e":           {"infra", 1},
+		"makefile":      {"infra", 2},
+		"breaking":      {"breaking", 3},
+		"deprecated":    {"breaking", 1},
+	}
+)
+
+// pathHint figures out the type that changing the given file generally
+// indicates, if any.
+func pathHint(file string) (string, bool) {
+	base := path.Base(file)
+	switch {
+	case strings.HasSuffix(base, ".md"), strings.HasPrefix(file, "docs/"):
+		return "docs", true
+	case strings.HasSuffix(base, "_test.go"), strings.HasPrefix(file, ".github/"), strings.HasPrefix(file, "hack/"),
+		base == "Makefile", base == "go.mod", base == "go.sum", base == "Dockerfile":
+		return "infra", true
+	default:
+		return "", false
+	}
+}
+
+// SuggestType guesses the type of an uncategorized PR using the current
+// taxonomy.  See Taxonomy.SuggestType.
+func SuggestType(title string, paths []string) (Suggestion, bool) {
+	return currentTaxonomy.SuggestType(title, paths)
+}
+
+// SuggestType guesses the type of a PR whose title has no prefix, based on
+// keywords and verbs in the title and (if available) the paths of the files
+// it changes.  If there are no hints, or the hints are evenly split between
+// types, there's no suggestion.
+func (t *Taxonomy) SuggestType(title string, paths []string) (Suggestion, bool) {
+	title = strings.TrimSpace(title)
+	scores := make(map[PRType]int)
+	reasons := make(map[PRType][]string)
+	addHint := func(hint typeHint, reason string) {
+		prType, known := t.ByName(hint.typeName)
+		if !known {
+			return
+		}
+		scores[prType] += hint.weight
+		reasons[prType] = append(reasons[prType], reason)
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, word := range words {
+		if hint, isVerb := verbHints[word]; isVerb && i == 0 {
+			addHint(hint, fmt.Sprintf("title starts with %q", word))
+			continue
+		}
+		if hint, isKeyword := keywordHints[word]; isKeyword {
+			addHint(hint, fmt.Sprintf("title mentions %q", word))
+		}
+	}
+
+	// paths only count in aggregate, so that one stray file doesn't dominate
+	pathCounts := make(map[string]int)
+	for _, file := range paths {
+		if typeName, hasHint := pathHint(file); hasHint {
+			pathCounts[typeName]++
+		}
+	}
+	for typeName, count := range pathCounts {
+		weight := 3 * count / len(paths)
+		if weight == 0 {
+			continue
+		}
+		addHint(typeHint{typeName, weight}, fmt.Sprintf("%d of %d changed files look like %s changes", count, len(paths), typeName))
+	}
+
+	if len(scores) == 0 {
+		return Suggestion{}, false
+	}
+
+	candidates := make([]PRType, 0, len(scores))
+	total := 0
+	for prType, score := range scores {
+		candidates = append(candidates, prType)
+		total += score
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return scores[candidates[i]] > scores[candidates[j]]
+	})
+	best := candidates[0]
+	if len(candidates) > 1 && scores[candidates[1]] == scores[best] {
+		return Suggestion{}, false
+	}
+
+	// how much the hints agree, scaled down if there's not much to go on
+	confidence := float64(scores[best]) / float64(total)
+	if scores[best] < 3 {
+		confidence *= float64(scores[best]) / 3
+	}
+
+	return Suggestion{
+		Type:       best,
+		Confidence: confidence,
+		Title:      t.prefixFor(best) + title,
+		Reasons:    reasons[best],
+	}, true
+}
+
+// prefixFor returns the canonical title prefix (including trailing space) for
+// the given type, according to this taxonomy's convention.
+func (t *Taxonomy) prefixFor(prType PRType) string {
+	info := t.Info(prType)
+	if t.convention == ConventionalCommits && len(info.Conventional) > 0 {
+		return info.Conventional[0] + ": "
+	}
+	return info.Shortcode() + " "
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("Suggesting types for uncategorized titles", func() {
+	It("should suggest a type based on the leading verb", func() {
+		suggestion, found := SuggestType("Fix goroutine leak in the controller", nil)
+		Expect(found).To(BeTrue())
+		Expect(suggestion.Type).To(Equal(BugfixPR))
+		Expect(suggestion.Title).To(Equal(":bug: Fix goroutine leak in the controller"))
+		Expect(suggestion.Reasons).To(Equal([]string{`title starts with "fix"`, `title mentions "leak"`}))
+		Expect(suggestion.Confidence).To(BeNumerically("==", 1))
+	})
+
+	It("should combine hints from the verb and keywords", func() {
+		suggestion, found := SuggestType("Fix typo in the README", nil)
+		Expect(found).To(BeTrue())
+		Expect(suggestion.Type).To(Equal(DocsPR))
+		Expect(suggestion.Title).To(Equal(":book: Fix typo in the README"))
+		Expect(suggestion.Confidence).To(BeNumerically("<", 1))
+	})
+
+	It("should be more confident when the hints agree", func() {
+		agreeing, found := SuggestType("Bump the linter version for CI", nil)
+		Expect(found).To(BeTrue())
+		Expect(agreeing.Type).To(Equal(InfraPR))
+
+		mixed, found := SuggestType("Bump version to fix panic", nil)
+		Expect(found).To(BeTrue())
+		Expect(mixed.Confidence).To(BeNumerically("<", agreeing.Confidence))
+	})
+
+	It("should use hints from the changed paths", func() {
+		suggestion, found := SuggestType("Explain how webhooks get certificates", []string{"docs/book/src/webhooks.md", "README.md"})
+		Expect(found).To(BeTrue())
+		Expect(suggestion.Type).To(Equal(DocsPR))
+	})
+
+	It("should not suggest anything without hints", func() {
+		_, found := SuggestType("Controller.Watch() should not store watches if already started", nil)
+		Expect(found).To(BeFalse())
+	})
+
+	It("should not suggest anything if the hints are evenly split", func() {
+		_, found := SuggestType("Add typo", nil)
+		Expect(found).To(BeFalse())
+	})
+
+	It("should use the taxonomy's convention for the suggested title", func() {
+		taxonomy := DefaultTaxonomy()
+		Expect(taxonomy.SetConvention(ConventionalCommits)).To(Succeed())
+
+		suggestion, found := taxonomy.SuggestType("Add Foo field", nil)
+		Expect(found).To(BeTrue())
+		Expect(suggestion.Title).To(Equal("feat: Add Foo field"))
+	})
+})
@@ -0,0 +1,425 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// SemverImpact is the minimum version bump that a given type of change
+// requires in the next final release.
+type SemverImpact string
+
+const (
+	ImpactPatch SemverImpact = "patch"
+	ImpactMinor SemverImpact = "minor"
+	ImpactMajor SemverImpact = "major"
+)
+
+// rank orders impacts from smallest to largest bump.
+func (i SemverImpact) rank() int {
+	switch i {
+	case ImpactMajor:
+		return 2
+	case ImpactMinor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Exceeds checks if this impact requires a larger bump than the other one.
+func (i SemverImpact) Exceeds(other SemverImpact) bool {
+	return i.rank() > other.rank()
+}
+
+// TypeInfo describes a single kind of PR: how to spot it in a title, and how
+// to present it in release notes and PR checks.
+type TypeInfo struct {
+	// Name identifies this type (e.g. "feature").  It's what PRType.String
+	// returns, and what's used to refer to optional sections.
+	Name string `json:"name"`
+	// Description is a short human-readable explanation of when to use this
+	// type (e.g. "Non-breaking feature").
+	Description string `json:"description,omitempty"`
+	// Emoji is the unicode prefix for this type.  It should be a single
+	// codepoint, without any variation selectors.
+	Emoji string `json:"emoji,omitempty"`
+	// Shortcodes are the GitHub text-form prefixes for this type.  The first
+	// one is considered canonical.
+	Shortcodes []string `json:"shortcodes,omitempty"`
+	// Deprecated are prefixes that are still recognized, but shouldn't be
+	// used for new PRs.
+	Deprecated []string `json:"deprecated,omitempty"`
+	// Conventional are the Conventional Commits types (e.g. "feat") that
+	// indicate this type.
+	Conventional []string `json:"conventional,omitempty"`
+	// Labels are the GitHub labels (e.g. "kind/bug") that indicate this
+	// type, for PRs without a recognized title prefix.
+	Labels []string `json:"labels,omitempty"`
+	// Section is the heading for this type in the release notes.
+	Section string `json:"section,omitempty"`
+	// Impact is the minimum version bump caused by PRs of this type.
+	Impact SemverImpact `json:"impact,omitempty"`
+	// Order controls where this type is listed, lowest first.
+	Order int `json:"order,omitempty"`
+	// Optional types are only shown in the release notes when explicitly
+	// requested.  Unset means required.
+	Optional *bool `json:"optional,omitempty"`
+}
+
+// IsOptional checks if this type is only shown in the release notes when
+// explicitly requested.
+func (i TypeInfo) IsOptional() bool {
+	return i.Optional != nil && *i.Optional
+}
+
+// Prefixes returns all prefixes that mark a title as this type, including
+// deprecated ones.
+func (i TypeInfo) Prefixes() []string {
+	var res []string
+	if i.Emoji != "" {
+		res = append(res, i.Emoji)
+	}
+	res = append(res, i.Shortcodes...)
+	return append(res, i.Deprecated...)
+}
+
+// isDeprecated checks if the given prefix is one of the deprecated ones.
+func (i TypeInfo) isDeprecated(prefix string) bool {
+	for _, deprecated := range i.Deprecated {
+		if deprecated == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// Shortcode returns the canonical GitHub shortcode for this type, or the
+// emoji if no shortcodes exist.
+func (i TypeInfo) Shortcode() string {
+	if len(i.Shortcodes) == 0 {
+		return i.Emoji
+	}
+	return i.Shortcodes[0]
+}
+
+// overlay replaces any set fields in this TypeInfo with the ones from the
+// other TypeInfo.
+func (i TypeInfo) overlay(other TypeInfo) TypeInfo {
+	if other.Description != "" {
+		i.Description = other.Description
+	}
+	if other.Emoji != "" {
+		i.Emoji = other.Emoji
+	}
+	if other.Shortcodes != nil {
+		i.Shortcodes = other.Shortcodes
+	}
+	if other.Deprecated != nil {
+		i.Deprecated = other.Deprecated
+	}
+	if other.Conventional != nil {
+		i.Conventional = other.Conventional
+	}
+	if other.Labels != nil {
+		i.Labels = other.Labels
+	}
+	if other.Section != "" {
+		i.Section = other.Section
+	}
+	if other.Impact != "" {
+		i.Impact = other.Impact
+	}
+	if other.Order != 0 {
+		i.Order = other.Order
+	}
+	if other.Optional != nil {
+		i.Optional = other.Optional
+	}
+	return i
+}
+
+// optional is used to mark built-in types as optional.
+var optional = true
+
+// defaultTypes are the built-in types, indexed by PRType.
+//
+// NB(directxman12): the emoji are single runes on purpose, since some folks' dev
+// environments like to inject extra combining characters into the mix
+// (generally variation selector 16, which indicates emoji presentation), so
+// we want to check that these are *just* the character without the combining
+// parts.
+var defaultTypes = []TypeInfo{
+	UncategorizedPR: {
+		Name: "uncategorized",
+		// never matched as a prefix, just used for display
+		Shortcodes: []string{":question:"},
+		Section:    "Sort these by hand",
+		Impact:     ImpactPatch,
+		Order:      1000,
+	},
+	BreakingPR: {
+		Name:        "breaking",
+		Description: "Breaking change",
+		Emoji:       string('⚠'),
+		Shortcodes:  []string{":warning:"},
+		Section:     "Breaking Changes",
+		Impact:      ImpactMajor,
+		Order:       10,
+	},
+	FeaturePR: {
+		Name:        "feature",
+		Description: "Non-breaking feature",
+		Emoji:       string('✨'),
+		Shortcodes:  []string{":sparkles:"},
+		// `feature` isn't actually in the spec, but is common enough
+		Conventional: []string{"feat", "feature"},
+		// api-change generally means new fields -- actually-breaking changes
+		// should still use the prefix (or be relabeled in the types file)
+		Labels:  []string{"kind/feature", "kind/api-change"},
+		Section: "New Features",
+		Impact:  ImpactMinor,
+		Order:   20,
+	},
+	BugfixPR: {
+		Name:         "bugfix",
+		Description:  "Patch fix",
+		Emoji:        string('🐛'),
+		Shortcodes:   []string{":bug:"},
+		Conventional: []string{"fix"},
+		Labels:       []string{"kind/bug", "kind/regression"},
+		Section:      "Bug Fixes",
+		Impact:       ImpactPatch,
+		Order:        30,
+	},
+	DocsPR: {
+		Name:         "docs",
+		Description:  "Docs",
+		Emoji:        string('📖'),
+		Shortcodes:   []string{":book:"},
+		Conventional: []string{"docs"},
+		Labels:       []string{"kind/documentation"},
+		Section:      "Documentation",
+		Impact:       ImpactPatch,
+		Order:        40,
+		Optional:     &optional,
+	},
+	InfraPR: {
+		Name:        "infra",
+		Description: "Infra/Tests/Other",
+		Emoji:       string('🌱'),
+		Shortcodes:  []string{":seedling:"},
+		// This has been deprecated in favor of :seedling:
+		Deprecated:   []string{string('🏃'), ":running:"},
+		Conventional: []string{"chore", "build", "ci", "test", "refactor", "perf", "style", "revert"},
+		Labels:       []string{"kind/cleanup", "kind/flake", "kind/failing-test"},
+		Section:      "Infra & Such",
+		Impact:       ImpactPatch,
+		Order:        50,
+		Optional:     &optional,
+	},
+	ReleasePR: {
+		Name:        "release",
+		Description: "Release",
+		Emoji:       string('🚀'),
+		Shortcodes:  []string{":rocket:"},
+		Section:     "Releases",
+		Impact:      ImpactPatch,
+		Order:       45,
+		Optional:    &optional,
+	},
+}
+
+// Taxonomy is the set of known PR types.  The built-in types always exist
+// (with the same PRType values), but may be customized, and additional types
+// may be added.
+type Taxonomy struct {
+	// types are indexed by PRType
+	types []TypeInfo
+	// convention is the way types are marked in titles.
+	convention TitleConvention
+}
+
+// DefaultTaxonomy returns a taxonomy containing just the built-in PR types.
+func DefaultTaxonomy() *Taxonomy {
+	types := make([]TypeInfo, len(defaultTypes))
+	copy(types, defaultTypes)
+	return &Taxonomy{types: types, convention: EmojiConvention}
+}
+
+// NewTaxonomy constructs a taxonomy from the built-in types, overlaying the
+// given ones on top.  Types with the same name as a built-in type override
+// the fields they set, while other types are added as new PRTypes.
+func NewTaxonomy(types ...TypeInfo) (*Taxonomy, error) {
+	tax := DefaultTaxonomy()
+	for _, info := range types {
+		if info.Name == "" {
+			return nil, fmt.Errorf("PR type with prefixes %q has no name", info.Prefixes())
+		}
+		if existing, known := tax.ByName(info.Name); known {
+			tax.types[existing] = tax.types[existing].overlay(info)
+			continue
+		}
+		if info.Impact == "" {
+			info.Impact = ImpactPatch
+		}
+		tax.types = append(tax.types, info)
+	}
+
+	if err := tax.validate(); err != nil {
+		return nil, err
+	}
+	return tax, nil
+}
+
+// validate checks that the impacts are known, and that no two types claim the
+// same prefix, Conventional Commits type, or label.
+func (t *Taxonomy) validate() error {
+	seen := make(map[string]string)
+	seenConventional := make(map[string]string)
+	seenLabels := make(map[string]string)
+	for _, info := range t.types {
+		switch info.Impact {
+		case ImpactPatch, ImpactMinor, ImpactMajor:
+		default:
+			return fmt.Errorf("PR type %q has unknown semver impact %q (must be patch|minor|major)", info.Name, info.Impact)
+		}
+		for _, prefix := range info.Prefixes() {
+			if other, exists := seen[prefix]; exists {
+				return fmt.Errorf("prefix %q is used by both the %q and %q PR types", prefix, other, info.Name)
+			}
+			seen[prefix] = info.Name
+		}
+		for _, headerType := range info.Conventional {
+			headerType = strings.ToLower(headerType)
+			if other, exists := seenConventional[headerType]; exists {
+				return fmt.Errorf("conventional commit type %q is used by both the %q and %q PR types", headerType, other, info.Name)
+			}
+			seenConventional[headerType] = info.Name
+		}
+		for _, label := range info.Labels {
+			if other, exists := seenLabels[label]; exists {
+				return fmt.Errorf("label %q is used by both the %q and %q PR types", label, other, info.Name)
+			}
+			seenLabels[label] = info.Name
+		}
+	}
+	return nil
+}
+
+// taxonomyFile is the on-disk form of a Taxonomy.
+type taxonomyFile struct {
+	// Convention is the way types are marked in titles (defaults to emoji).
+	Convention TitleConvention `json:"convention,omitempty"`
+	Types      []TypeInfo      `json:"types"`
+}
+
+// ParseTaxonomy parses a YAML or JSON taxonomy definition, overlaying it on the
+// built-in types as per NewTaxonomy.
+func ParseTaxonomy(raw []byte) (*Taxonomy, error) {
+	var file taxonomyFile
+	if err := yaml.UnmarshalStrict(raw, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse PR type definitions: %w", err)
+	}
+	tax, err := NewTaxonomy(file.Types...)
+	if err != nil {
+		return nil, err
+	}
+	if file.Convention != "" {
+		if err := tax.SetConvention(file.Convention); err != nil {
+			return nil, err
+		}
+	}
+	return tax, nil
+}
+
+// LoadTaxonomy reads a YAML or JSON taxonomy definition from the given file.
+func LoadTaxonomy(path string) (*Taxonomy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read PR type definitions: %w", err)
+	}
+	return ParseTaxonomy(raw)
+}
+
+// Convention returns the way types are marked in titles.
+func (t *Taxonomy) Convention() TitleConvention {
+	return t.convention
+}
+
+// SetConvention changes the way types are marked in titles.
+func (t *Taxonomy) SetConvention(convention TitleConvention) error {
+	if err := convention.Validate(); err != nil {
+		return err
+	}
+	t.convention = convention
+	return nil
+}
+
+// Info returns the information for the given type.
+func (t *Taxonomy) Info(prType PRType) TypeInfo {
+	if int(prType) < 0 || int(prType) >= len(t.types) {
+		panic(fmt.Sprintf("unrecognized PR type %d", int(prType)))
+	}
+	return t.types[prType]
+}
+
+// ByName looks up a type by its name.
+func (t *Taxonomy) ByName(name string) (PRType, bool) {
+	for i, info := range t.types {
+		if info.Name == name {
+			return PRType(i), true
+		}
+	}
+	return UncategorizedPR, false
+}
+
+// Types returns all types in this taxonomy, sorted by their order.
+func (t *Taxonomy) Types() []PRType {
+	res := make([]PRType, len(t.types))
+	for i := range t.types {
+		res[i] = PRType(i)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return t.types[res[i]].Order < t.types[res[j]].Order
+	})
+	return res
+}
+
+// currentTaxonomy is the taxonomy used by PRType's methods and PRTypeFromTitle.
+var currentTaxonomy = DefaultTaxonomy()
+
+// SetTaxonomy replaces the taxonomy used by PRTypeFromTitle and the PRType
+// methods.  A nil taxonomy resets to the default one.
+func SetTaxonomy(tax *Taxonomy) {
+	if tax == nil {
+		tax = DefaultTaxonomy()
+	}
+	currentTaxonomy = tax
+}
+
+// CurrentTaxonomy returns the taxonomy used by PRTypeFromTitle and the PRType
+// methods.
+func CurrentTaxonomy() *Taxonomy {
+	return currentTaxonomy
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("PR type taxonomies", func() {
+	It("should contain just the built-in types by default", func() {
+		taxonomy := DefaultTaxonomy()
+		Expect(taxonomy.Types()).To(Equal([]PRType{
+			BreakingPR, FeaturePR, BugfixPR, DocsPR, ReleasePR, InfraPR, UncategorizedPR,
+		}))
+		Expect(taxonomy.Info(FeaturePR).Section).To(Equal("New Features"))
+		Expect(taxonomy.Info(FeaturePR).Shortcode()).To(Equal(":sparkles:"))
+		Expect(taxonomy.Info(BreakingPR).Impact).To(Equal(ImpactMajor))
+		Expect(taxonomy.Info(DocsPR).IsOptional()).To(BeTrue())
+	})
+
+	Context("when loading custom types", func() {
+		taxonomy, err := ParseTaxonomy([]byte(`
+types:
+- name: security
+  description: Security fix
+  emoji: 🔒
+  shortcodes: [":lock:"]
+  section: Security Fixes
+  order: 15
+- name: deprecation
+  emoji: 🗑
+  shortcodes: [":wastebasket:"]
+  section: Deprecations
+  impact: minor
+  order: 25
+- name: docs
+  section: Docs & Examples
+  optional: false
+`))
+
+		It("should load without error", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should add new types after the built-in ones, sorted by order", func() {
+			security, known := taxonomy.ByName("security")
+			Expect(known).To(BeTrue())
+			Expect(security).To(BeNumerically(">", ReleasePR))
+
+			deprecation, known := taxonomy.ByName("deprecation")
+			Expect(known).To(BeTrue())
+
+			Expect(taxonomy.Types()).To(Equal([]PRType{
+				BreakingPR, security, FeaturePR, deprecation, BugfixPR, DocsPR, ReleasePR, InfraPR, UncategorizedPR,
+			}))
+		})
+
+		It("should default the impact of new types to a patch bump", func() {
+			security, _ := taxonomy.ByName("security")
+			Expect(taxonomy.Info(security).Impact).To(Equal(ImpactPatch))
+		})
+
+		It("should only override the fields that were set on built-in types", func() {
+			docs := taxonomy.Info(DocsPR)
+			Expect(docs.Section).To(Equal("Docs & Examples"))
+			Expect(docs.IsOptional()).To(BeFalse())
+			Expect(docs.Emoji).To(Equal("📖"))
+			Expect(docs.Shortcodes).To(Equal([]string{":book:"}))
+		})
+
+		It("should parse titles using both the new and built-in prefixes", func() {
+			prType, title := taxonomy.TypeFromTitle(":lock: Don't log the token")
+			Expect(taxonomy.Info(prType).Name).To(Equal("security"))
+			Expect(title).To(Equal("Don't log the token"))
+
+			prType, title = taxonomy.TypeFromTitle("🗑️ Deprecate the old client")
+			Expect(taxonomy.Info(prType).Name).To(Equal("deprecation"))
+			Expect(title).To(Equal("Deprecate the old client"))
+
+			prType, title = taxonomy.TypeFromTitle(":bug: Fix the thing")
+			Expect(prType).To(Equal(BugfixPR))
+			Expect(title).To(Equal("Fix the thing"))
+		})
+	})
+
+	It("should accept JSON definitions", func() {
+		taxonomy, err := ParseTaxonomy([]byte(`{"types": [{"name": "security", "shortcodes": [":lock:"]}]}`))
+		Expect(err).NotTo(HaveOccurred())
+		_, known := taxonomy.ByName("security")
+		Expect(known).To(BeTrue())
+	})
+
+	It("should reject types without names", func() {
+		_, err := NewTaxonomy(TypeInfo{Shortcodes: []string{":lock:"}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject prefixes claimed by multiple types", func() {
+		_, err := NewTaxonomy(TypeInfo{Name: "shiny", Shortcodes: []string{":sparkles:"}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject unknown semver impacts", func() {
+		_, err := NewTaxonomy(TypeInfo{Name: "security", Impact: "huge"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject unknown fields", func() {
+		_, err := ParseTaxonomy([]byte(`{"types": [{"name": "security", "emojis": ["🔒"]}]}`))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("when set as the current taxonomy", func() {
+		AfterEach(func() {
+			SetTaxonomy(nil)
+		})
+
+		It("should be used by PRTypeFromTitle and the PRType methods", func() {
+			taxonomy, err := NewTaxonomy(TypeInfo{Name: "security", Emoji: "🔒", Shortcodes: []string{":lock:"}})
+			Expect(err).NotTo(HaveOccurred())
+			SetTaxonomy(taxonomy)
+
+			prType, title := PRTypeFromTitle(":lock: Don't log the token")
+			Expect(prType.String()).To(Equal("security"))
+			Expect(prType.Emoji()).To(Equal("🔒"))
+			Expect(title).To(Equal("Don't log the token"))
+		})
+	})
+})
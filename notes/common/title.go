@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"regexp"
+	"strings"
+)
+
+// areaRE matches an `[area]` or `(area)` marker at the start of a title.
+var areaRE = regexp.MustCompile(`^(?:\[(?P<bracket>[^\[\]]+)\]|\((?P<paren>[^()]+)\))[[:space:]]*`)
+
+// ParsedTitle is the result of parsing a PR title.
+type ParsedTitle struct {
+	// Type is the type of the PR.
+	Type PRType
+	// Prefix is the text that indicated the type, exactly as it appeared in
+	// the title (e.g. `:sparkles:` or `fix(webhook)!: `).  It's empty for
+	// uncategorized titles.
+	Prefix string
+	// Deprecated indicates that the prefix is still recognized, but shouldn't
+	// be used any more.
+	Deprecated bool
+	// Area is the part of the project this PR touches, from an `[area]` or
+	// `(area)` marker after the prefix, or a Conventional Commits scope.
+	Area string
+	// Title is the title without the prefix.
+	Title string
+	// Subject is the title without the prefix or area.
+	Subject string
+	// Warnings describe anything that was cleaned up in order to parse the
+	// title.
+	Warnings []string
+}
+
+// ParseTitle parses a PR title using the current taxonomy.
+func ParseTitle(title string) ParsedTitle {
+	return currentTaxonomy.Parse(title, "")
+}
+
+// ParseTitleAndBody is like ParseTitle, but also considers the PR body (e.g.
+// for Conventional Commits breaking-change footers).
+func ParseTitleAndBody(title, body string) ParsedTitle {
+	return currentTaxonomy.Parse(title, body)
+}
+
+// Parse parses a PR title (and optionally body) according to this taxonomy's
+// convention.  Titles without a recognized prefix are UncategorizedPR.
+func (t *Taxonomy) Parse(title, body string) ParsedTitle {
+	title, warnings := NormalizeTitle(title)
+
+	if len(title) == 0 {
+		return ParsedTitle{Type: UncategorizedPR, Warnings: warnings}
+	}
+
+	var res ParsedTitle
+	switch t.convention {
+	case ConventionalCommits:
+		res = t.parseConventional(title, body)
+	case AnyConvention:
+		if res = t.parseEmoji(title); res.Type == UncategorizedPR {
+			res = t.parseConventional(title, body)
+		}
+	default:
+		res = t.parseEmoji(title)
+	}
+
+	res.Warnings = append(warnings, res.Warnings...)
+	return res
+}
+
+// SplitArea splits an `[area]` or `(area)` marker off the start of a
+// (prefix-less) title, returning the area and the rest of the title.
+func SplitArea(title string) (area, subject string) {
+	parts := areaRE.FindStringSubmatch(title)
+	if parts == nil {
+		return "", title
+	}
+	area = parts[areaRE.SubexpIndex("bracket")] + parts[areaRE.SubexpIndex("paren")]
+	return strings.TrimSpace(area), title[len(parts[0]):]
+}
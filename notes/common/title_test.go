@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+var _ = Describe("Structured PR title parsing", func() {
+	DescribeTable("emoji titles",
+		func(title string, expected ParsedTitle) {
+			Expect(ParseTitle(title)).To(Equal(expected))
+		},
+		Entry("should record the matched emoji", "✨ Add CreateOrPatch", ParsedTitle{
+			Type: FeaturePR, Prefix: "✨", Title: "Add CreateOrPatch", Subject: "Add CreateOrPatch",
+		}),
+		Entry("should record the matched shortcode", ":bug: Fix the webhook", ParsedTitle{
+			Type: BugfixPR, Prefix: ":bug:", Title: "Fix the webhook", Subject: "Fix the webhook",
+		}),
+		Entry("should flag deprecated prefixes", ":running: Run faster", ParsedTitle{
+			Type: InfraPR, Prefix: ":running:", Deprecated: true, Title: "Run faster", Subject: "Run faster",
+		}),
+		Entry("should extract a bracketed area", ":bug: [0.6] Fix the webhook", ParsedTitle{
+			Type: BugfixPR, Prefix: ":bug:", Area: "0.6", Title: "[0.6] Fix the webhook", Subject: "Fix the webhook",
+		}),
+		Entry("should extract a parenthesized area", "📖 (book) Use relative links", ParsedTitle{
+			Type: DocsPR, Prefix: "📖", Area: "book", Title: "(book) Use relative links", Subject: "Use relative links",
+		}),
+		Entry("should not extract an area from the middle of the title", ":bug: Fix the webhook [again]", ParsedTitle{
+			Type: BugfixPR, Prefix: ":bug:", Title: "Fix the webhook [again]", Subject: "Fix the webhook [again]",
+		}),
+		Entry("should warn about stripped variation selectors", "✨️Truly sparkly", ParsedTitle{
+			Type: FeaturePR, Prefix: "✨", Title: "Truly sparkly", Subject: "Truly sparkly",
+			Warnings: []string{"removed invisible character U+FE0F from the prefix"},
+		}),
+		Entry("should leave uncategorized titles alone", "[0.6] blah blah", ParsedTitle{
+			Type: UncategorizedPR, Title: "[0.6] blah blah", Subject: "[0.6] blah blah",
+		}),
+	)
+
+	It("should use the Conventional Commits header as the prefix and the scope as the area", func() {
+		taxonomy := DefaultTaxonomy()
+		Expect(taxonomy.SetConvention(ConventionalCommits)).To(Succeed())
+		Expect(taxonomy.Parse("fix(webhook)!: change the default port", "")).To(Equal(ParsedTitle{
+			Type: BreakingPR, Prefix: "fix(webhook)!: ", Area: "webhook",
+			Title: "change the default port", Subject: "change the default port",
+		}))
+	})
+
+	It("should keep PRTypeFromTitle as a shorthand", func() {
+		prType, title := PRTypeFromTitle(":bug: [0.6] Fix the webhook")
+		Expect(prType).To(Equal(BugfixPR))
+		Expect(title).To(Equal("[0.6] Fix the webhook"))
+	})
+})
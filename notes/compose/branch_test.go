@@ -32,35 +32,33 @@ var _ = Describe("Branches", func() {
 		branch := ReleaseBranch{Version: semver.Version{Major: 1}}
 		It("should return ReleaseTag if there was a release in this branch's history", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag("v1.3.4"), nil
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return []git.Tag{"v1.3.4"}, nil
 				},
 			}
 
-			Expect(branch.LatestRelease(gitImpl, false)).To(Equal(ReleaseTag(
-				semver.Version{Major: 1, Minor: 3, Patch: 4},
-			)))
+			Expect(branch.LatestRelease(gitImpl, false)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 3, Patch: 4}}))
 		})
 
 		It("should support pre-release ReleaseTags", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag("v1.3.4-alpha.6"), nil
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return []git.Tag{"v1.3.4-alpha.6"}, nil
 				},
 			}
 
-			Expect(branch.LatestRelease(gitImpl, false)).To(Equal(ReleaseTag(
-				semver.Version{Major: 1, Minor: 3, Patch: 4, Pre: []semver.PRVersion{
+			Expect(branch.LatestRelease(gitImpl, false)).To(Equal(ReleaseTag{
+				Version: semver.Version{Major: 1, Minor: 3, Patch: 4, Pre: []semver.PRVersion{
 					{VersionStr: "alpha"},
 					{VersionNum: 6, IsNum: true},
 				}},
-			)))
+			}))
 		})
 
 		It("should return FirstCommit if no release exists yet", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag(""), fmt.Errorf("no tag found!")
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return nil, fmt.Errorf("no tag found!")
 				},
 				firstCommit: func(branchName string) (git.Commit, error) {
 					return git.Commit("abcdef"), nil
@@ -74,8 +72,8 @@ var _ = Describe("Branches", func() {
 
 		It("should fail if no release exists and the first commit cannot be found", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag(""), fmt.Errorf("no tag found!")
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return nil, fmt.Errorf("no tag found!")
 				},
 				firstCommit: func(branchName string) (git.Commit, error) {
 					return git.Commit(""), fmt.Errorf("infinite parallel lines, non-euclidean git repository encountered!")
@@ -85,10 +83,35 @@ var _ = Describe("Branches", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
+		It("should pick the highest release belonging to the branch, ignoring other tags", func() {
+			gitImpl := gitFuncs{
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return []git.Tag{"latest", "tools/setup-envtest/v1.9.0", "v0.6.3", "v1", "v1.10.0", "v1.3.4", "v1.9.0", "v2.0.0-alpha.0"}, nil
+				},
+			}
+
+			Expect(branch.LatestRelease(gitImpl, true)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 10}}))
+		})
+
+		It("should fall back to the first commit if there are only non-release tags", func() {
+			gitImpl := gitFuncs{
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return []git.Tag{"latest", "v1"}, nil
+				},
+				firstCommit: func(branchName string) (git.Commit, error) {
+					return git.Commit("abcdef"), nil
+				},
+			}
+			Expect(branch.LatestRelease(gitImpl, false)).To(Equal(FirstCommit{
+				Branch: branch,
+				Commit: git.Commit("abcdef"),
+			}))
+		})
+
 		It("should reject tags from the wrong branch if asked to verify tags", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag("v0.6.7"), nil
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return []git.Tag{"v0.6.7"}, nil
 				},
 			}
 
@@ -98,14 +121,12 @@ var _ = Describe("Branches", func() {
 
 		It("should accept tags from the wrong branch if not asked to verify tags", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag("v0.6.7"), nil
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return []git.Tag{"v0.6.7"}, nil
 				},
 			}
 
-			Expect(branch.LatestRelease(gitImpl, false)).To(Equal(ReleaseTag(
-				semver.Version{Minor: 6, Patch: 7},
-			)))
+			Expect(branch.LatestRelease(gitImpl, false)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 6, Patch: 7}}))
 		})
 	})
 
@@ -113,42 +134,51 @@ var _ = Describe("Branches", func() {
 		Context("when dealing with release-X branches", func() {
 			branch := ReleaseBranch{Version: semver.Version{Major: 2}}
 			It("should accept tags with matching X versions", func() {
-				tag := ReleaseTag(semver.Version{Major: 2, Minor: 3, Patch: 1})
+				tag := ReleaseTag{Version: semver.Version{Major: 2, Minor: 3, Patch: 1}}
 				Expect(branch.VerifyTagBelongs(tag)).To(Succeed())
 			})
 			It("should reject tags with different X versions", func() {
-				tag := ReleaseTag(semver.Version{Major: 1, Minor: 3, Patch: 1})
+				tag := ReleaseTag{Version: semver.Version{Major: 1, Minor: 3, Patch: 1}}
 				Expect(branch.VerifyTagBelongs(tag)).NotTo(Succeed())
 			})
 		})
 		Context("when dealing with release-0.Y branches", func() {
 			branch := ReleaseBranch{Version: semver.Version{Minor: 3}}
 			It("should accept tags with matching Y versions", func() {
-				tag := ReleaseTag(semver.Version{Major: 0, Minor: 3, Patch: 6})
+				tag := ReleaseTag{Version: semver.Version{Major: 0, Minor: 3, Patch: 6}}
 				Expect(branch.VerifyTagBelongs(tag)).To(Succeed())
 			})
 			It("should reject tags with different Y versions", func() {
-				tag := ReleaseTag(semver.Version{Major: 0, Minor: 4, Patch: 1})
+				tag := ReleaseTag{Version: semver.Version{Major: 0, Minor: 4, Patch: 1}}
 				Expect(branch.VerifyTagBelongs(tag)).NotTo(Succeed())
 			})
 			It("should reject tags with non-zero X versions", func() {
 				// NB: matching X version here
-				tag := ReleaseTag(semver.Version{Major: 1, Minor: 3})
+				tag := ReleaseTag{Version: semver.Version{Major: 1, Minor: 3}}
 				Expect(branch.VerifyTagBelongs(tag)).NotTo(Succeed())
 			})
 		})
+		Context("when dealing with development branches", func() {
+			It("should accept any tag", func() {
+				branch := DevelopmentBranch("main")
+				Expect(branch.VerifyTagBelongs(ReleaseTag{Version: semver.Version{Major: 2, Minor: 3}})).To(Succeed())
+				Expect(branch.VerifyTagBelongs(ReleaseTag{Version: semver.Version{Minor: 7}})).To(Succeed())
+			})
+		})
 	})
 
 	Describe("creating from a raw branch name", func() {
 		It("should accept release-X branches as vX.0.0 versions", func() {
 			Expect(ReleaseFromBranch("release-2")).To(Equal(ReleaseBranch{
 				Version: semver.Version{Major: 2},
+				Scheme:  KubebuilderBranches,
 			}))
 		})
 
 		It("should accept release-0.Y branches as v0.Y.0 versions", func() {
 			Expect(ReleaseFromBranch("release-0.3")).To(Equal(ReleaseBranch{
 				Version: semver.Version{Minor: 3},
+				Scheme:  KubebuilderBranches,
 			}))
 		})
 
@@ -173,6 +203,56 @@ var _ = Describe("Branches", func() {
 		})
 	})
 
+	Describe("with other branch naming schemes", func() {
+		It("should parse & print per-minor branches", func() {
+			scheme := BranchPattern("release-{major}.{minor}")
+			branch, err := ParseReleaseBranch(scheme, "release-1.28")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal(ReleaseBranch{Version: semver.Version{Major: 1, Minor: 28}, Scheme: scheme}))
+			Expect(branch.String()).To(Equal("release-1.28"))
+		})
+
+		It("should support other prefixes & separators", func() {
+			scheme := BranchPattern("release-v{major}.{minor}")
+			Expect(ParseReleaseBranch(scheme, "release-v1.5")).To(Equal(ReleaseBranch{Version: semver.Version{Major: 1, Minor: 5}, Scheme: scheme}))
+
+			scheme = BranchPattern("release/{major}.x")
+			branch, err := ParseReleaseBranch(scheme, "release/2.x")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch.Version).To(Equal(semver.Version{Major: 2}))
+			Expect(branch.String()).To(Equal("release/2.x"))
+		})
+
+		It("should reject branches that don't match the pattern", func() {
+			scheme := BranchPattern("release-{major}.{minor}")
+			for _, name := range []string{"release-1", "release-v1.28", "release-1.28.1", "main"} {
+				_, err := ParseReleaseBranch(scheme, name)
+				Expect(err).To(HaveOccurred(), name)
+			}
+		})
+
+		It("should only accept tags from the branch's line", func() {
+			branch := ReleaseBranch{Version: semver.Version{Major: 1, Minor: 28}, Scheme: BranchPattern("release-{major}.{minor}")}
+			Expect(branch.VerifyTagBelongs(ReleaseTag{Version: semver.MustParse("1.28.3")})).To(Succeed())
+			Expect(branch.VerifyTagBelongs(ReleaseTag{Version: semver.MustParse("1.29.0")})).NotTo(Succeed())
+			Expect(branch.VerifyTagBelongs(ReleaseTag{Version: semver.MustParse("2.28.0")})).NotTo(Succeed())
+
+			branch = ReleaseBranch{Version: semver.Version{Major: 0}, Scheme: BranchPattern("release-{major}")}
+			Expect(branch.VerifyTagBelongs(ReleaseTag{Version: semver.MustParse("0.3.0")})).To(Succeed())
+		})
+
+		It("should parse scheme names & reject patterns without the right placeholders", func() {
+			Expect(ParseBranchScheme("")).To(Equal(KubebuilderBranches))
+			Expect(ParseBranchScheme("kubebuilder")).To(Equal(KubebuilderBranches))
+			Expect(ParseBranchScheme("release-{major}.{minor}")).To(Equal(BranchPattern("release-{major}.{minor}")))
+
+			for _, raw := range []string{"release-{minor}", "release-{major}.{major}", "release-{major}.{minor}.{minor}"} {
+				_, err := ParseBranchScheme(raw)
+				Expect(err).To(HaveOccurred(), raw)
+			}
+		})
+	})
+
 	Describe("when printing/reinterpreting", func() {
 		It("should print vX.y.z as release-X", func() {
 			branch := ReleaseBranch{Version: semver.Version{Major: 2}}
@@ -191,5 +271,13 @@ var _ = Describe("Branches", func() {
 			branch = ReleaseBranch{Version: semver.Version{Major: 2}, UseUpstream: true}
 			Expect(branch.String()).To(Equal("release-2@{u}"))
 		})
+
+		It("should print just the name of the local branch when asked, even if using upstream branches", func() {
+			branch := ReleaseBranch{Version: semver.Version{Minor: 3}, UseUpstream: true}
+			Expect(branch.Local().String()).To(Equal("release-0.3"))
+			Expect(branch.UseUpstream).To(BeTrue())
+
+			Expect(DevelopmentBranch("main").Local().String()).To(Equal("main"))
+		})
 	})
 })
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
+)
+
+// BranchScheme is a way of naming release branches.  Each release branch
+// covers a release line: all the versions that share the line's major
+// version (for per-major branches), or its major & minor versions (for
+// per-minor ones).
+type BranchScheme interface {
+	// Parse returns the release line covered by the branch with the given
+	// name, or an error if it's not a release branch in this scheme.
+	Parse(branchName string) (semver.Version, error)
+	// Format returns the name of the branch covering the given release line.
+	Format(line semver.Version) string
+	// Line returns the release line containing the given version (its
+	// version truncated to what branches cover, e.g. 1.28.3 --> 1.28 for
+	// per-minor branches).
+	Line(version semver.Version) semver.Version
+}
+
+// KubebuilderBranches is the scheme used by the KubeBuilder projects (see
+// VERSIONING.md): `release-0.Y` branches before 1.0, and `release-X` branches
+// after.  This is the default.
+var KubebuilderBranches BranchScheme = kubebuilderScheme{}
+
+var (
+	releaseRE = regexp.MustCompile(`^release-((?:0\.(?P<minor>[[:digit:]]+))|(?P<major>[[:digit:]]+))$`)
+)
+
+// kubebuilderScheme implements KubebuilderBranches.
+type kubebuilderScheme struct{}
+
+func (kubebuilderScheme) Parse(branchName string) (semver.Version, error) {
+	parts := releaseRE.FindStringSubmatch(branchName)
+	if parts == nil {
+		return semver.Version{}, fmt.Errorf("%q is not a valid release branch (release-0.Y or release-X)", branchName)
+	}
+	minorRaw := parts[releaseRE.SubexpIndex("minor")]
+	majorRaw := parts[releaseRE.SubexpIndex("major")]
+	switch {
+	case minorRaw != "":
+		minor, err := strconv.ParseUint(minorRaw, 10, 64)
+		if err != nil {
+			return semver.Version{}, fmt.Errorf("could not parse minor version from %q: %w", minorRaw, err)
+		}
+		if minor == 0 {
+			return semver.Version{}, fmt.Errorf("release-0.0 is not a valid release")
+		}
+		return semver.Version{Major: 0, Minor: minor}, nil
+	case majorRaw != "":
+		major, err := strconv.ParseUint(majorRaw, 10, 64)
+		if err != nil {
+			return semver.Version{}, fmt.Errorf("could not parse major version from %q: %w", majorRaw, err)
+		}
+		if major == 0 {
+			return semver.Version{}, fmt.Errorf("release-0 is not a valid release")
+		}
+		return semver.Version{Major: major}, nil
+	default:
+		return semver.Version{}, fmt.Errorf("%q is not a valid release branch (release-0.Y or release-X)", branchName)
+	}
+}
+
+func (kubebuilderScheme) Format(line semver.Version) string {
+	if line.Major == 0 {
+		return fmt.Sprintf("release-0.%d", line.Minor)
+	}
+	return fmt.Sprintf("release-%d", line.Major)
+}
+
+func (kubebuilderScheme) Line(version semver.Version) semver.Version {
+	if version.Major == 0 {
+		return semver.Version{Minor: version.Minor}
+	}
+	return semver.Version{Major: version.Major}
+}
+
+// BranchPattern is a BranchScheme that names branches according to a
+// pattern containing `{major}`, and optionally `{minor}`, placeholders, like
+// `release-{major}.{minor}`, `release-v{major}.{minor}`, or
+// `release/{major}.x`.  Patterns with `{minor}` have per-minor branches, and
+// ones without have per-major branches.
+type BranchPattern string
+
+// Validate checks that this pattern has the placeholders it needs.
+func (p BranchPattern) Validate() error {
+	pattern := string(p)
+	if strings.Count(pattern, "{major}") != 1 || strings.Count(pattern, "{minor}") > 1 {
+		return fmt.Errorf("invalid branch pattern %q (must contain {major} once, and {minor} at most once)", pattern)
+	}
+	return nil
+}
+
+// perMinor checks if this pattern has per-minor branches.
+func (p BranchPattern) perMinor() bool {
+	return strings.Contains(string(p), "{minor}")
+}
+
+// regexp converts this pattern to a regular expression matching branch
+// names.
+func (p BranchPattern) regexp() *regexp.Regexp {
+	expr := regexp.QuoteMeta(string(p))
+	expr = strings.Replace(expr, regexp.QuoteMeta("{major}"), `(?P<major>[[:digit:]]+)`, 1)
+	expr = strings.Replace(expr, regexp.QuoteMeta("{minor}"), `(?P<minor>[[:digit:]]+)`, 1)
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+func (p BranchPattern) Parse(branchName string) (semver.Version, error) {
+	if err := p.Validate(); err != nil {
+		return semver.Version{}, err
+	}
+	re := p.regexp()
+	parts := re.FindStringSubmatch(branchName)
+	if parts == nil {
+		return semver.Version{}, fmt.Errorf("%q is not a valid release branch (%s)", branchName, string(p))
+	}
+	var res semver.Version
+	var err error
+	if res.Major, err = strconv.ParseUint(parts[re.SubexpIndex("major")], 10, 64); err != nil {
+		return semver.Version{}, fmt.Errorf("could not parse major version from %q: %w", branchName, err)
+	}
+	if p.perMinor() {
+		if res.Minor, err = strconv.ParseUint(parts[re.SubexpIndex("minor")], 10, 64); err != nil {
+			return semver.Version{}, fmt.Errorf("could not parse minor version from %q: %w", branchName, err)
+		}
+	}
+	return res, nil
+}
+
+func (p BranchPattern) Format(line semver.Version) string {
+	res := strings.Replace(string(p), "{major}", strconv.FormatUint(line.Major, 10), 1)
+	return strings.Replace(res, "{minor}", strconv.FormatUint(line.Minor, 10), 1)
+}
+
+func (p BranchPattern) Line(version semver.Version) semver.Version {
+	if p.perMinor() {
+		return semver.Version{Major: version.Major, Minor: version.Minor}
+	}
+	return semver.Version{Major: version.Major}
+}
+
+// precedesLine checks if prev is the release line right before line: the
+// previous minor version, or, for the first line of a major version, any line
+// from the previous major version (e.g. v0.6 before v1, v1.30 before v2.0).
+func precedesLine(prev, line semver.Version) bool {
+	if prev.Major == line.Major {
+		return line.Minor > 0 && prev.Minor == line.Minor-1
+	}
+	return line.Minor == 0 && prev.Major+1 == line.Major
+}
+
+// ParseBranchScheme parses a branch scheme from its name on the command line:
+// either "kubebuilder" (or empty) for KubebuilderBranches, or a BranchPattern.
+func ParseBranchScheme(raw string) (BranchScheme, error) {
+	if raw == "" || raw == "kubebuilder" {
+		return KubebuilderBranches, nil
+	}
+	pattern := BranchPattern(raw)
+	if err := pattern.Validate(); err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
@@ -17,12 +17,16 @@ limitations under the License.
 package compose_test
 
 import (
+	"fmt"
+	"strconv"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git/gittest"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/pulls"
 )
 
 func TestCompose(t *testing.T) {
@@ -31,19 +35,30 @@ func TestCompose(t *testing.T) {
 }
 
 type gitFuncs struct {
-	closestTag           func(initial git.Committish) (git.Tag, error)
+	versionTags          func(prefix string) ([]git.Tag, error)
+	mergedTags           func(into git.Committish) ([]git.Tag, error)
 	firstCommit          func(branchName string) (git.Commit, error)
 	hasUpstream          func(branchName string) error
-	mergeCommitsBetween  func(start, end git.Committish) (string, error)
+	mergeCommitsBetween  func(start, end git.Committish) ([]git.CommitRecord, error)
+	firstParentBetween   func(start, end git.Committish) ([]git.CommitRecord, error)
+	verifyTag            func(tag git.Tag) (git.TagSignature, error)
+	changedFiles         func(commit git.Commit) ([]string, error)
+	patchID              func(commit git.Commit) (string, error)
 	remoteForUpstreamFor func(branchName string) (string, error)
 	urlForRemote         func(remote string) (string, error)
 }
 
-func (f gitFuncs) ClosestTag(initial git.Committish) (git.Tag, error) {
-	if f.closestTag == nil {
-		panic("ClosestTag not expected")
+func (f gitFuncs) VersionTags(prefix string) ([]git.Tag, error) {
+	if f.versionTags == nil {
+		panic("VersionTags not expected")
 	}
-	return f.closestTag(initial)
+	return f.versionTags(prefix)
+}
+func (f gitFuncs) MergedTags(into git.Committish) ([]git.Tag, error) {
+	if f.mergedTags == nil {
+		panic("MergedTags not expected")
+	}
+	return f.mergedTags(into)
 }
 func (f gitFuncs) FirstCommit(branchName string) (git.Commit, error) {
 	if f.firstCommit == nil {
@@ -57,9 +72,77 @@ func (f gitFuncs) HasUpstream(branchName string) error {
 	}
 	return f.hasUpstream(branchName)
 }
-func (f gitFuncs) MergeCommitsBetween(start, end git.Committish) (string, error) {
+func (f gitFuncs) MergeCommitsBetween(start, end git.Committish) ([]git.CommitRecord, error) {
 	if f.mergeCommitsBetween == nil {
 		panic("MergeCommitsBetween not expected")
 	}
 	return f.mergeCommitsBetween(start, end)
 }
+func (f gitFuncs) FirstParentCommitsBetween(start, end git.Committish) ([]git.CommitRecord, error) {
+	if f.firstParentBetween == nil {
+		panic("FirstParentCommitsBetween not expected")
+	}
+	return f.firstParentBetween(start, end)
+}
+func (f gitFuncs) VerifyTag(tag git.Tag) (git.TagSignature, error) {
+	if f.verifyTag == nil {
+		panic("VerifyTag not expected")
+	}
+	return f.verifyTag(tag)
+}
+func (f gitFuncs) ChangedFiles(commit git.Commit) ([]string, error) {
+	if f.changedFiles == nil {
+		panic("ChangedFiles not expected")
+	}
+	return f.changedFiles(commit)
+}
+func (f gitFuncs) PatchID(commit git.Commit) (string, error) {
+	if f.patchID == nil {
+		panic("PatchID not expected")
+	}
+	return f.patchID(commit)
+}
+
+// mergeCommit constructs a merge commit record with the given SHA, subject,
+// and body (the rest of the metadata isn't relevant to most tests).
+func mergeCommit(sha, subject, body string) git.CommitRecord {
+	return git.CommitRecord{
+		SHA:     git.Commit(sha),
+		Parents: []git.Commit{"0000000000000000000000000000000000000001", "0000000000000000000000000000000000000002"},
+		Subject: subject,
+		Body:    body,
+	}
+}
+
+// fakePulls is a pulls.Source that serves PRs from a map.
+type fakePulls map[string]pulls.Info
+
+func (f fakePulls) PullRequest(number string) (pulls.Info, error) {
+	info, known := f[number]
+	if !known {
+		return pulls.Info{}, fmt.Errorf("no such PR #%s", number)
+	}
+	return info, nil
+}
+
+func (f fakePulls) PullRequestFor(commit string) (pulls.Info, bool, error) {
+	return pulls.Info{}, false, nil
+}
+
+// repoPulls is a pulls.Source that knows which PRs the commits in a gittest
+// repository came from (see gittest.Repo.PRFor).
+type repoPulls struct {
+	repo *gittest.Repo
+}
+
+func (r repoPulls) PullRequest(number string) (pulls.Info, error) {
+	return pulls.Info{Number: number}, nil
+}
+
+func (r repoPulls) PullRequestFor(commit string) (pulls.Info, bool, error) {
+	number, found := r.repo.PRFor(git.Commit(commit))
+	if !found {
+		return pulls.Info{}, false, nil
+	}
+	return pulls.Info{Number: strconv.Itoa(number)}, true, nil
+}
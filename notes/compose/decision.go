@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+// VersionRule is one of the rules ExpectedNextVersion follows to pick the
+// next version.
+type VersionRule string
+
+const (
+	// RuleFirstRelease starts at v0.1.0, since nothing's been released yet.
+	RuleFirstRelease VersionRule = "first-release"
+	// RuleInitialVersion starts at the configured initial version, since
+	// nothing's been released yet (see ReleaseInfo.Initial).
+	RuleInitialVersion VersionRule = "initial-version"
+	// RulePreToFinal makes the final release of the current pre-release's
+	// version.
+	RulePreToFinal VersionRule = "pre-to-final"
+	// RulePreIncrement makes another pre-release of the same kind as the
+	// current one.
+	RulePreIncrement VersionRule = "pre-increment"
+	// RulePreAdvance makes the first pre-release of a later kind (e.g. beta
+	// after alpha) of the current pre-release's version.
+	RulePreAdvance VersionRule = "pre-advance"
+	// RuleBreakingBump bumps X for breaking changes.
+	RuleBreakingBump VersionRule = "breaking-bump"
+	// RulePre10Clamp bumps Y for breaking changes to 0.Y releases, instead of
+	// going to v1.0.0 (see ReleaseInfo.Pre10).
+	RulePre10Clamp VersionRule = "pre10-clamp"
+	// RuleFeatureBump bumps Y for new features.
+	RuleFeatureBump VersionRule = "feature-bump"
+	// RuleNewLine bumps Y for a new release line, even without new features
+	// (see ReleaseInfo.NewLine).
+	RuleNewLine VersionRule = "new-line"
+	// RulePatchBump bumps Z for anything else.
+	RulePatchBump VersionRule = "patch-bump"
+)
+
+// Description explains this rule for humans.
+func (r VersionRule) Description() string {
+	switch r {
+	case RuleFirstRelease:
+		return "nothing has been released yet, so this is the first release"
+	case RuleInitialVersion:
+		return "nothing has been released yet, so this is the configured initial version"
+	case RulePreToFinal:
+		return "the final release of the current pre-release's version"
+	case RulePreIncrement:
+		return "another pre-release of the same kind as the current one"
+	case RulePreAdvance:
+		return "the first pre-release of a later kind than the current one, keeping its version"
+	case RuleBreakingBump:
+		return "breaking changes bump the major version"
+	case RulePre10Clamp:
+		return "breaking changes bump the minor version of 0.Y releases, instead of going to v1.0.0"
+	case RuleFeatureBump:
+		return "new features bump the minor version"
+	case RuleNewLine:
+		return "new release lines bump at least the minor version"
+	case RulePatchBump:
+		return "there are no new features or breaking changes, so just bump the patch version"
+	default:
+		return string(r)
+	}
+}
+
+// VersionTrigger is a change that triggered the rule behind a version
+// decision.
+type VersionTrigger struct {
+	// Type is the name of the change's PR type.
+	Type     string `json:"type"`
+	PRNumber string `json:"pr"`
+	Title    string `json:"title"`
+}
+
+// VersionDecision records why ExplainNextVersion picked the next version.
+type VersionDecision struct {
+	// Current is the release (or commit, if there isn't one) that the
+	// version was computed from.
+	Current string `json:"current"`
+	// Next is the chosen version.
+	Next ReleaseTag `json:"next"`
+	// Rule is the rule that picked Next.
+	Rule VersionRule `json:"rule"`
+	// Triggers are the changes that caused the rule's version bump, if the
+	// rule depends on the changes.
+	Triggers []VersionTrigger `json:"triggers,omitempty"`
+}
+
+func (d VersionDecision) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s (from %s): %s", d.Next, d.Current, d.Rule.Description())
+	if len(d.Triggers) > 0 {
+		out.WriteString(", because of:")
+		for _, trigger := range d.Triggers {
+			fmt.Fprintf(&out, "\n  - [%s] %s (#%s)", trigger.Type, trigger.Title, trigger.PRNumber)
+		}
+	}
+	return out.String()
+}
+
+// triggers lists the changes with the given impact, which are the ones that
+// caused a bump of that size.
+func (l ChangeLog) triggers(impact common.SemverImpact) []VersionTrigger {
+	taxonomy := common.CurrentTaxonomy()
+	var res []VersionTrigger
+	for _, prType := range taxonomy.Types() {
+		typeImpact := taxonomy.Info(prType).Impact
+		if typeImpact.Exceeds(impact) || impact.Exceeds(typeImpact) {
+			continue
+		}
+		for _, entry := range l.Entries(prType) {
+			res = append(res, VersionTrigger{Type: prType.String(), PRNumber: entry.PRNumber, Title: entry.Title})
+		}
+	}
+	return res
+}
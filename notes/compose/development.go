@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	golog "log"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+)
+
+// DevelopmentBranch returns a "release branch" for the given development
+// branch (like main or master), for previewing the next minor or major
+// release before its release branch is cut.
+func DevelopmentBranch(name string) ReleaseBranch {
+	return ReleaseBranch{Development: name}
+}
+
+// IsDevelopment checks if this is actually a development branch (see
+// DevelopmentBranch).
+func (b ReleaseBranch) IsDevelopment() bool {
+	return b.Development != ""
+}
+
+// LatestTaggedRelease finds the most recent release (by version) out of all
+// the release tags with the given prefix (see ReleaseBranch.TagPrefix) in the
+// repository, reachable from any particular branch or not.  It returns nil if
+// there haven't been any releases.
+func LatestTaggedRelease(gitImpl git.Git, prefix string) (*ReleaseTag, error) {
+	tags, err := gitImpl.VersionTags(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list release tags: %w", err)
+	}
+	return highestRelease(parseReleaseTags(tags, prefix), func(ReleaseTag) bool { return true }), nil
+}
+
+// developmentVersion picks the release to list changes on the given
+// development branch since, given the latest release reachable from it.  Most releases
+// are tagged on release branches, so the latest reachable one is generally
+// just the first release of the current line (e.g. v0.6.0, when v0.6.3 is
+// out), so this prefers the latest release overall, if it's newer.  Merges
+// from the development branch that were backported to that release end up
+// listed again, just like on a freshly-cut release branch.
+func developmentVersion(gitImpl git.Git, branch ReleaseBranch, latestHere git.Committish) (git.Committish, error) {
+	latest, err := LatestTaggedRelease(gitImpl, branch.TagPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		return latestHere, nil
+	}
+	if tag, isTag := latestHere.(ReleaseTag); isTag && tag.GE(latest.Version) {
+		return tag, nil
+	}
+	golog.Printf("latest release overall is %q, using that instead of %q", latest.Committish(), latestHere.Committish())
+	return *latest, nil
+}
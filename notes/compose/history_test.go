@@ -0,0 +1,485 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/blang/semver/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/compose"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git/gittest"
+)
+
+// withoutCommits clears the commit information from the entries in a
+// changelog, since the SHAs & dates from the fixtures aren't interesting to
+// compare against.
+func withoutCommits(log ChangeLog) ChangeLog {
+	for _, prType := range common.CurrentTaxonomy().Types() {
+		entries := log.Entries(prType)
+		for i := range entries {
+			entries[i].Commit, entries[i].Author, entries[i].MergedAt = "", "", time.Time{}
+		}
+	}
+	return log
+}
+
+var _ = Describe("Realistic histories", func() {
+	var (
+		repo           *gittest.Repo
+		main, rel6     *gittest.Branch
+		release6Branch ReleaseBranch
+	)
+	BeforeEach(func() {
+		// main:        init -- #1100 (v0.6.0) -- #1160 -- #1170 -- (release-0.7 forks here)
+		//                          \
+		// release-0.6:              #1161 (v0.6.1) -- #1171 (v0.6.2)
+		repo = gittest.NewRepo()
+		main = repo.Branch("main")
+		main.Commit("Initial commit")
+		main.MergePR(1100, ":sparkles: Add the webhook server")
+		main.Tag("v0.6.0")
+
+		rel6 = main.Fork("release-0.6")
+		rel6.MergePR(1161, ":bug: Fix leaking goroutine")
+		rel6.Tag("v0.6.1")
+		rel6.MergePR(1171, ":bug: Ensure that webhook server is thread/start-safe")
+		rel6.Tag("v0.6.2")
+		rel6.Push()
+
+		main.MergePR(1160, ":sparkles: Add Foo field")
+		main.MergePR(1170, ":bug: Ensure that webhook server is thread/start-safe")
+
+		release6Branch = ReleaseBranch{Version: semver.Version{Minor: 6}}
+	})
+
+	Describe("finding the current version", func() {
+		It("should find the latest release on the branch", func() {
+			Expect(CurrentVersion(repo, &release6Branch)).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.2")}))
+		})
+
+		It("should look at the previous release branch for new release branches", func() {
+			main.Fork("release-0.7")
+			branch := ReleaseBranch{Version: semver.Version{Minor: 7}}
+			Expect(CurrentVersion(repo, &branch)).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.2")}))
+		})
+
+		It("should ignore tags that aren't valid releases, even if they're closer", func() {
+			rel6.LightweightTag("latest")
+			rel6.Tag("tools/setup-envtest/v0.1.0")
+			rel6.Tag("v0.7")
+			Expect(CurrentVersion(repo, &release6Branch)).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.2")}))
+		})
+
+		It("should pick the highest version, not the closest tag", func() {
+			rel6.MergePR(1180, ":bug: Fix another thing")
+			rel6.Tag("v0.6.10")
+			rel6.Tag("v0.6.9")
+			Expect(CurrentVersion(repo, &release6Branch)).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.10")}))
+		})
+
+		It("should fall back to the first commit if nothing's been released", func() {
+			repo := gittest.NewRepo()
+			branch := repo.Branch("release-0.1")
+			first := branch.Commit("Initial commit")
+			branch.MergePR(1, ":sparkles: Add the client")
+
+			relBranch := ReleaseBranch{Version: semver.Version{Minor: 1}}
+			Expect(CurrentVersion(repo, &relBranch)).To(Equal(FirstCommit{Branch: relBranch, Commit: first}))
+		})
+
+		It("should use the upstream if it exists and was requested", func() {
+			rel6.MergePR(1180, ":bug: Not pushed yet")
+			rel6.Tag("v0.6.3")
+
+			release6Branch.UseUpstream = true
+			Expect(CurrentVersion(repo, &release6Branch)).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.2")}))
+			Expect(release6Branch.UseUpstream).To(BeTrue())
+		})
+
+		It("should clear UseUpstream if there's no upstream", func() {
+			branch := ReleaseBranch{Version: semver.Version{Minor: 7}, UseUpstream: true}
+			main.Fork("release-0.7")
+			_, err := CurrentVersion(repo, &branch)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch.UseUpstream).To(BeFalse())
+		})
+	})
+
+	Describe("previewing releases from the development branch", func() {
+		It("should start from the latest release overall", func() {
+			branch := DevelopmentBranch("main")
+			Expect(branch.String()).To(Equal("main"))
+			Expect(CurrentVersion(repo, &branch)).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.2")}))
+		})
+
+		It("should list the changes merged since the latest release branch was cut", func() {
+			branch := DevelopmentBranch("main")
+			log, since, err := Changes(repo, &branch)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(since).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.2")}))
+			Expect(log).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Features: []LogEntry{{PRNumber: "1160", Title: "Add Foo field"}},
+				Bugs:     []LogEntry{{PRNumber: "1170", Title: "Ensure that webhook server is thread/start-safe"}},
+			})))
+
+			next, err := log.ExpectedNextVersion(since, ReleaseInfo{Pre10: true, NewLine: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(next).To(Equal(ReleaseTag{Version: semver.MustParse("0.7.0")}))
+		})
+
+		It("should start from the latest release reachable from the branch, if that's newer", func() {
+			main.Tag("v0.7.0-beta.0")
+			branch := DevelopmentBranch("main")
+			Expect(CurrentVersion(repo, &branch)).To(Equal(ReleaseTag{Version: semver.MustParse("0.7.0-beta.0")}))
+		})
+
+		It("should fall back to the first commit if nothing's been released", func() {
+			repo := gittest.NewRepo()
+			main := repo.Branch("main")
+			first := main.Commit("Initial commit")
+			main.MergePR(1, ":sparkles: Add the client")
+
+			branch := DevelopmentBranch("main")
+			Expect(CurrentVersion(repo, &branch)).To(Equal(FirstCommit{Branch: branch, Commit: first}))
+		})
+	})
+
+	Describe("listing changes", func() {
+		It("should list the PRs merged into the branch since the given version, most recent first", func() {
+			rel7 := main.Fork("release-0.7")
+			Expect(ChangesSince(repo, ReleaseBranch{Version: semver.Version{Minor: 7}}, ReleaseTag{Version: semver.MustParse("0.6.0")})).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Features: []LogEntry{{PRNumber: "1160", Title: "Add Foo field"}},
+				Bugs:     []LogEntry{{PRNumber: "1170", Title: "Ensure that webhook server is thread/start-safe"}},
+			})))
+			Expect(rel7.Tip()).To(Equal(main.Tip()))
+		})
+
+		It("should only include changes from the upstream when using it", func() {
+			rel6.MergePR(1180, ":bug: Not pushed yet")
+			release6Branch.UseUpstream = true
+
+			log, since, err := Changes(repo, &release6Branch)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(since).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.2")}))
+			Expect(log).To(Equal(ChangeLog{}))
+
+			release6Branch.UseUpstream = false
+			log, _, err = Changes(repo, &release6Branch)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Bugs: []LogEntry{{PRNumber: "1180", Title: "Not pushed yet"}},
+			})))
+		})
+
+		It("should skip cherry-picks that weren't merged via PR", func() {
+			rel6.CherryPick(repo.Commit("Merge pull request #1160 from someone/pr-1160\n\n:sparkles: Add Foo field"))
+			rel6.MergePR(1181, ":bug: Backport the goroutine fix")
+
+			log, _, err := Changes(repo, &release6Branch)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Bugs: []LogEntry{{PRNumber: "1181", Title: "Backport the goroutine fix"}},
+			})))
+		})
+	})
+
+	Describe("listing changes from squash & rebase merges", func() {
+		var rel7 *gittest.Branch
+		BeforeEach(func() {
+			rel7 = main.Fork("release-0.7")
+			rel7.SquashPR(1200, ":sparkles: Add Bar field")
+			rel7.RebasePR(1201, "Refactor the webhook server", ":bug: Fix panic on shutdown", ":seedling: Bump deps")
+			rel7.Commit("Fix typo, pushed directly")
+			rel7.MergePR(1202, ":book: Document Bar field")
+			rel7.SquashPR(1203, ":bug: Fix Bar defaulting")
+		})
+		branch := func(strategy MergeStrategy) ReleaseBranch {
+			return ReleaseBranch{Version: semver.Version{Minor: 7}, MergeStrategy: strategy, CommitPRs: repoPulls{repo: repo}}
+		}
+		since := ReleaseTag{Version: semver.MustParse("0.6.0")}
+
+		It("should take PR numbers from the trailing (#N) on each commit with the squash strategy", func() {
+			Expect(ChangesSince(repo, branch(MergeStrategySquash), since)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Features: []LogEntry{{PRNumber: "1200", Title: "Add Bar field"}},
+				Bugs:     []LogEntry{{PRNumber: "1203", Title: "Fix Bar defaulting"}},
+			})))
+		})
+
+		It("should group runs of commits from the same PR with the rebase strategy", func() {
+			Expect(ChangesSince(repo, branch(MergeStrategyRebase), since)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Features: []LogEntry{{PRNumber: "1200", Title: "Add Bar field"}},
+				Bugs: []LogEntry{
+					{PRNumber: "1203", Title: "Fix Bar defaulting"},
+					{PRNumber: "1201", Title: "Fix panic on shutdown"},
+				},
+			})))
+		})
+
+		It("should skip rebase-merged commits if there's no way to look up their PRs", func() {
+			relBranch := branch(MergeStrategyRebase)
+			relBranch.CommitPRs = nil
+			Expect(ChangesSince(repo, relBranch, since)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Features: []LogEntry{{PRNumber: "1200", Title: "Add Bar field"}},
+				Bugs:     []LogEntry{{PRNumber: "1203", Title: "Fix Bar defaulting"}},
+			})))
+		})
+
+		It("should still group runs of commits that record their PR in the title with the rebase strategy", func() {
+			rel7.SquashPR(1204, "Split the Bar webhook")
+			rel7.SquashPR(1204, ":sparkles: Validate Bar")
+
+			relBranch := branch(MergeStrategyRebase)
+			relBranch.CommitPRs = nil
+			Expect(ChangesSince(repo, relBranch, since)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Features: []LogEntry{
+					{PRNumber: "1204", Title: "Validate Bar"},
+					{PRNumber: "1200", Title: "Add Bar field"},
+				},
+				Bugs: []LogEntry{{PRNumber: "1203", Title: "Fix Bar defaulting"}},
+			})))
+		})
+
+		It("should accept merge commits as well as squash & rebase merges with the auto strategy", func() {
+			Expect(ChangesSince(repo, branch(MergeStrategyAuto), since)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Features: []LogEntry{
+					{PRNumber: "1200", Title: "Add Bar field"},
+					{PRNumber: "1160", Title: "Add Foo field"},
+				},
+				Bugs: []LogEntry{
+					{PRNumber: "1203", Title: "Fix Bar defaulting"},
+					{PRNumber: "1201", Title: "Fix panic on shutdown"},
+					{PRNumber: "1170", Title: "Ensure that webhook server is thread/start-safe"},
+				},
+				Docs: []LogEntry{{PRNumber: "1202", Title: "Document Bar field"}},
+			})))
+		})
+
+		It("should record the most recent commit for each PR", func() {
+			log, err := ChangesSince(repo, branch(MergeStrategyRebase), since)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log.Bugs).To(HaveLen(2))
+
+			entry := log.Bugs[1]
+			Expect(entry.PRNumber).To(Equal("1201"))
+			Expect(entry.Commit).To(Equal(repo.Commit(":seedling: Bump deps")))
+			Expect(entry.Author).To(Equal("Some Contributor"))
+			Expect(entry.MergedAt).NotTo(BeZero())
+		})
+
+		Context("with Conventional Commits", func() {
+			BeforeEach(func() {
+				taxonomy := common.DefaultTaxonomy()
+				Expect(taxonomy.SetConvention(common.ConventionalCommits)).To(Succeed())
+				common.SetTaxonomy(taxonomy)
+			})
+			AfterEach(func() {
+				common.SetTaxonomy(nil)
+			})
+
+			It("should mark squash merges with a breaking-change footer as breaking", func() {
+				rel8 := main.Fork("release-0.8")
+				rel8.Commit("feat: switch leader election to leases (#1210)\n\nBREAKING CHANGE: configmap locks are no longer supported")
+				rel8.SquashPR(1211, "fix: handle nil clients")
+
+				branch := ReleaseBranch{Version: semver.Version{Minor: 8}, MergeStrategy: MergeStrategySquash}
+				Expect(ChangesSince(repo, branch, since)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+					Breaking: []LogEntry{{PRNumber: "1210", Title: "switch leader election to leases"}},
+					Bugs:     []LogEntry{{PRNumber: "1211", Title: "handle nil clients"}},
+				})))
+			})
+
+			It("should mark rebase merges with a breaking-change footer on any commit as breaking", func() {
+				rel8 := main.Fork("release-0.8")
+				rel8.Commit("feat: add cache options (#1212)\n\nBREAKING CHANGE: the informer map is gone")
+				rel8.SquashPR(1212, "docs: document the cache options")
+
+				branch := ReleaseBranch{Version: semver.Version{Minor: 8}, MergeStrategy: MergeStrategyRebase}
+				Expect(ChangesSince(repo, branch, since)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+					Breaking: []LogEntry{{PRNumber: "1212", Title: "add cache options"}},
+				})))
+			})
+		})
+
+		It("should only find merge commits with the merge strategy", func() {
+			Expect(ChangesSince(repo, branch(MergeStrategyMerge), since)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+				Features: []LogEntry{{PRNumber: "1160", Title: "Add Foo field"}},
+				Bugs:     []LogEntry{{PRNumber: "1170", Title: "Ensure that webhook server is thread/start-safe"}},
+				Docs:     []LogEntry{{PRNumber: "1202", Title: "Document Bar field"}},
+			})))
+		})
+	})
+
+	Describe("finding the closest final release", func() {
+		It("should skip pre-releases and non-release tags", func() {
+			main.Tag("v0.7.0-alpha.0")
+			main.MergePR(1190, ":sparkles: Add Bar field")
+			main.LightweightTag("some-other-tag")
+			main.MergePR(1191, ":sparkles: Add Baz field")
+			main.Tag("v0.7.0-beta.0")
+
+			Expect(ClosestFinal(repo, ReleaseTag{Version: semver.MustParse("0.7.0-beta.0")})).To(Equal(&ReleaseTag{Version: semver.Version{Minor: 6}}))
+		})
+
+		It("should find the previous final release from a final release", func() {
+			main.MergePR(1190, ":sparkles: Add Bar field")
+			main.Tag("v0.7.0")
+
+			Expect(ClosestFinal(repo, ReleaseTag{Version: semver.MustParse("0.7.0")})).To(Equal(&ReleaseTag{Version: semver.Version{Minor: 6}}))
+		})
+
+		It("should fail if there's no earlier final release", func() {
+			_, err := ClosestFinal(repo, ReleaseTag{Version: semver.MustParse("0.6.0")})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Histories with separately-tagged modules", func() {
+	const envtest = "tools/setup-envtest"
+	var (
+		repo      *gittest.Repo
+		main      *gittest.Branch
+		relBranch ReleaseBranch
+	)
+	BeforeEach(func() {
+		// main: init (v0.6.0) -- #1200 (tools/setup-envtest/v0.1.0) -- (release-0.7 forks here)
+		// release-0.7: #1210 (envtest) -- #1211 (client) -- #1212 (both)
+		repo = gittest.NewRepo()
+		main = repo.Branch("main")
+		main.Changing("go.mod").Commit("Initial commit")
+		main.Tag("v0.6.0")
+		main.Changing(envtest+"/go.mod").MergePR(1200, ":sparkles: Add setup-envtest")
+		main.Tag(envtest + "/v0.1.0")
+
+		rel7 := main.Fork("release-0.7")
+		rel7.Changing(envtest+"/main.go").MergePR(1210, ":bug: Fix envtest downloads")
+		rel7.Changing("pkg/client/client.go").MergePR(1211, ":bug: Fix client panic")
+		rel7.Changing("go.mod", envtest+"/go.mod").MergePR(1212, ":seedling: Bump deps")
+
+		relBranch = ReleaseBranch{Version: semver.Version{Minor: 7}, Paths: []string{envtest}, TagPrefix: envtest + "/"}
+	})
+	v0_1_0 := ReleaseTag{Version: semver.MustParse("0.1.0"), Prefix: envtest + "/"}
+
+	It("should name release tags with the prefix", func() {
+		Expect(v0_1_0.Committish()).To(Equal("tools/setup-envtest/v0.1.0"))
+		Expect(json.Marshal(v0_1_0)).To(MatchJSON(`"tools/setup-envtest/v0.1.0"`))
+	})
+
+	It("should find the module's latest release, no matter the branch", func() {
+		Expect(CurrentVersion(repo, &relBranch)).To(Equal(v0_1_0))
+	})
+
+	It("should keep the module's releases apart from the main module's", func() {
+		module := DevelopmentBranch("main")
+		module.TagPrefix = envtest + "/"
+		Expect(CurrentVersion(repo, &module)).To(Equal(v0_1_0))
+
+		mainModule := DevelopmentBranch("main")
+		Expect(CurrentVersion(repo, &mainModule)).To(Equal(ReleaseTag{Version: semver.MustParse("0.6.0")}))
+	})
+
+	It("should only accept releases named with the prefix", func() {
+		Expect(relBranch.VerifyTagBelongs(v0_1_0)).To(Succeed())
+		Expect(relBranch.VerifyTagBelongs(ReleaseTag{Version: semver.MustParse("0.1.0")})).To(MatchError(ContainSubstring("not named like the branch's releases")))
+	})
+
+	It("should only list PRs that touch the module's path", func() {
+		Expect(ChangesSince(repo, relBranch, v0_1_0)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+			Bugs:  []LogEntry{{PRNumber: "1210", Title: "Fix envtest downloads"}},
+			Infra: []LogEntry{{PRNumber: "1212", Title: "Bump deps"}},
+		})))
+	})
+
+	It("should list PRs whose commits touch the module's path with the rebase strategy", func() {
+		rel7 := repo.Branch("release-0.7")
+		rel7.Changing("pkg/client/client.go").RebasePR(1220, ":sparkles: Add a client option", ":bug: Fix the option's default")
+		rel7.Changing("README.md").SquashPR(1221, ":book: Mention the option")
+		rel7.Changing(envtest+"/main.go").SquashPR(1220, ":seedling: Use the option in setup-envtest")
+
+		relBranch.MergeStrategy = MergeStrategyRebase
+		relBranch.CommitPRs = repoPulls{repo: repo}
+		Expect(ChangesSince(repo, relBranch, v0_1_0)).To(WithTransform(withoutCommits, Equal(ChangeLog{
+			Infra: []LogEntry{{PRNumber: "1220", Title: "Use the option in setup-envtest"}},
+		})))
+	})
+
+	It("should find the module's previous final release", func() {
+		rel7 := repo.Branch("release-0.7")
+		rel7.Tag("v0.7.0")
+		rel7.Tag(envtest + "/v0.2.0-rc.0")
+
+		Expect(ClosestFinal(repo, ReleaseTag{Version: semver.MustParse("0.2.0-rc.0"), Prefix: envtest + "/"})).To(Equal(&v0_1_0))
+	})
+})
+
+var _ = Describe("Histories with per-minor release branches", func() {
+	var (
+		repo   *gittest.Repo
+		main   *gittest.Branch
+		scheme = BranchPattern("release-{major}.{minor}")
+	)
+	BeforeEach(func() {
+		// main:         init -- #100 (v1.27.0) -- #110 -- (release-1.28 forks here)
+		//                          \
+		// release-1.27:              #101 (v1.27.1)
+		repo = gittest.NewRepo()
+		main = repo.Branch("main")
+		main.Commit("Initial commit")
+		main.MergePR(100, ":sparkles: Add the webhook server")
+		main.Tag("v1.27.0")
+
+		rel27 := main.Fork("release-1.27")
+		rel27.MergePR(101, ":bug: Fix leaking goroutine")
+		rel27.Tag("v1.27.1")
+		rel27.Push()
+
+		main.MergePR(110, ":sparkles: Add Foo field")
+	})
+
+	It("should find the latest release on the branch", func() {
+		branch, err := ParseReleaseBranch(scheme, "release-1.27")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(CurrentVersion(repo, &branch)).To(Equal(ReleaseTag{Version: semver.MustParse("1.27.1")}))
+	})
+
+	It("should look at the previous minor's branch for new release branches", func() {
+		main.Fork("release-1.28")
+		branch, err := ParseReleaseBranch(scheme, "release-1.28")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(CurrentVersion(repo, &branch)).To(Equal(ReleaseTag{Version: semver.MustParse("1.27.1")}))
+	})
+
+	It("should accept the last minor of the previous major for a new major's branch", func() {
+		main.Fork("release-2.0")
+		branch, err := ParseReleaseBranch(scheme, "release-2.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(CurrentVersion(repo, &branch)).To(Equal(ReleaseTag{Version: semver.MustParse("1.27.1")}))
+	})
+
+	It("should reject releases from lines other than the previous one", func() {
+		main.Fork("release-1.29")
+		branch, err := ParseReleaseBranch(scheme, "release-1.29")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = CurrentVersion(repo, &branch)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -18,40 +18,37 @@ package compose_test
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/blang/semver/v4"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
 	. "sigs.k8s.io/kubebuilder-release-tools/notes/compose"
 	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
 )
 
 var (
-	shortishCommitList = `commit ac380d61764a160b32946e606b0c9ecd2834e3e8
-Merge pull request #1165 from vincepri/backpor06-1163
-
-:bug: [0.6] Controller.Watch() should not store watches if already started
-commit 29c2e320531ea96428e10e4cca49e48751cf4ce5
-Merge pull request #1137 from vincepri/update-jsonpatch490-06
-
-🌱 [0.6] Update json-patch to v4.9.0
-`
+	shortishCommitList = []git.CommitRecord{
+		mergeCommit("ac380d61764a160b32946e606b0c9ecd2834e3e8", "Merge pull request #1165 from vincepri/backpor06-1163", ":bug: [0.6] Controller.Watch() should not store watches if already started"),
+		mergeCommit("29c2e320531ea96428e10e4cca49e48751cf4ce5", "Merge pull request #1137 from vincepri/update-jsonpatch490-06", "🌱 [0.6] Update json-patch to v4.9.0"),
+	}
 	shortishChangeLog = ChangeLog{
-		Bugs:  []LogEntry{{Title: "[0.6] Controller.Watch() should not store watches if already started", PRNumber: "1165"}},
-		Infra: []LogEntry{{Title: "[0.6] Update json-patch to v4.9.0", PRNumber: "1137"}},
+		Bugs:  []LogEntry{{Title: "[0.6] Controller.Watch() should not store watches if already started", PRNumber: "1165", Commit: "ac380d61764a160b32946e606b0c9ecd2834e3e8", Area: "0.6"}},
+		Infra: []LogEntry{{Title: "[0.6] Update json-patch to v4.9.0", PRNumber: "1137", Commit: "29c2e320531ea96428e10e4cca49e48751cf4ce5", Area: "0.6"}},
 	}
 )
 
 var _ = Describe("Change Logs", func() {
 	It("should be able to just figure out the latest version if we don't ask for a specific one", func() {
 		gitImpl := gitFuncs{
-			closestTag: func(initial git.Committish) (git.Tag, error) {
-				return git.Tag("v0.6.3"), nil
+			mergedTags: func(into git.Committish) ([]git.Tag, error) {
+				return []git.Tag{"v0.6.3"}, nil
 			},
-			mergeCommitsBetween: func(start, end git.Committish) (string, error) {
+			mergeCommitsBetween: func(start, end git.Committish) ([]git.CommitRecord, error) {
 				if start.Committish() != "v0.6.3" || end.Committish() != "release-0.6" {
-					return "", fmt.Errorf("couldn't find commits for unexpected range %s..%s", start.Committish(), end.Committish())
+					return nil, fmt.Errorf("couldn't find commits for unexpected range %s..%s", start.Committish(), end.Committish())
 				}
 				return shortishCommitList, nil
 			},
@@ -60,15 +57,15 @@ var _ = Describe("Change Logs", func() {
 
 		log, since, err := Changes(gitImpl, &currBranch)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(since).To(Equal(ReleaseTag(semver.Version{Minor: 6, Patch: 3})))
+		Expect(since).To(Equal(ReleaseTag{Version: semver.Version{Minor: 6, Patch: 3}}))
 		Expect(log).NotTo(Equal(ChangeLog{})) // just don't be empty, we'll test other things later
 	})
 
 	It("should use the specified start point when we specify one", func() {
 		gitImpl := gitFuncs{
-			mergeCommitsBetween: func(start, end git.Committish) (string, error) {
+			mergeCommitsBetween: func(start, end git.Committish) ([]git.CommitRecord, error) {
 				if start.Committish() != "abcdef" || end.Committish() != "release-0.6" {
-					return "", fmt.Errorf("couldn't find commits for unexpected range %s..%s", start.Committish(), end.Committish())
+					return nil, fmt.Errorf("couldn't find commits for unexpected range %s..%s", start.Committish(), end.Committish())
 				}
 				return shortishCommitList, nil
 			},
@@ -82,9 +79,9 @@ var _ = Describe("Change Logs", func() {
 
 	It("should fail if we can't get the merge commits", func() {
 		gitImpl := gitFuncs{
-			mergeCommitsBetween: func(start, end git.Committish) (string, error) {
+			mergeCommitsBetween: func(start, end git.Committish) ([]git.CommitRecord, error) {
 				// note for non-native speakers: "accidentally the X" is a meme-y colloquialism
-				return "", fmt.Errorf("couldn't find the commits -- did you accidentally the repository?")
+				return nil, fmt.Errorf("couldn't find the commits -- did you accidentally the repository?")
 			},
 		}
 		currBranch := ReleaseBranch{Version: semver.Version{Minor: 6}}
@@ -95,52 +92,22 @@ var _ = Describe("Change Logs", func() {
 
 	It("should turn merge commits into changelog entries", func() {
 		gitImpl := gitFuncs{
-			mergeCommitsBetween: func(start, end git.Committish) (string, error) {
-				return (
+			mergeCommitsBetween: func(start, end git.Committish) ([]git.CommitRecord, error) {
 				// a decent sampling of different commits -- at least
 				// one of each type, but not necessarily one of each indicator.
 				// The full range of indicators is tested in common.
-				`commit 6af4e7c71d4ca149837d2ed9a33fd8df98ac6103
-Merge pull request #1187 from vincepri/go115
-
-:seedling: Update Go mod version to 1.15
-commit fdc6658a141b99a3fcb733c8a8000f98e6666f48
-Merge pull request #850 from akutz/feature/createOrPatch
-
-✨CreateOrPatch
-commit be18097a47bdf9341e31a700cc1c2c23ebb48e42
-Merge pull request #1176 from prafull01/multi-apitype
-
-:sparkles: Add error check for multiple apiTypes as reconciliation object
-commit 5757a389803ec368126bb1ff046ae3524dacbfcf
-Merge pull request #1155 from DirectXMan12/bug/webhook-server-threadsafe
-
-:bug: Ensure that webhook server is thread/start-safe
-commit ea6a506eb2b74d17606171d46675da4ec4053c5b
-Merge pull request #1075 from alvaroaleman/add
-
-:running: Proposal to extract cluster-specifics out of the Manager
-commit 22a2c58a47971ab46c2ff8fab1bf6494632cd1f5
-Merge pull request #1160 from daniel-hutao/patch-1
-
-update Builder.Register() 's comment - one or more
-commit 20af9010491c4e97a6d77219d8c22db9b99aa491
-Merge pull request #1163 from vincepri/watches-controller-bug
-
-🐛 Controller.Watch() should not store watches if already started
-commit d6829e9c4db802eb4d5703d22c6cd87e8bbf91da
-Merge pull request #1153 from gogolok/fix_typo
-
-:book: Fix typo
-commit 4717461d1f66687d3a82288d3131302d64f11389
-Merge pull request #1144 from alvaroaleman/default-le-resourcelock
-
-⚠ Change leaderlock from ConfigMap to ConfigMapsLeasesResourceLock
-commit be59d6426fe904ea87b348d49503112b8eb5ccef
-Merge pull request #1129 from Shpectator/admission-webhooks-status-response
-
-:warning: admission responses with raw Status
-`), nil
+				return []git.CommitRecord{
+					mergeCommit("6af4e7c71d4ca149837d2ed9a33fd8df98ac6103", "Merge pull request #1187 from vincepri/go115", ":seedling: Update Go mod version to 1.15"),
+					mergeCommit("fdc6658a141b99a3fcb733c8a8000f98e6666f48", "Merge pull request #850 from akutz/feature/createOrPatch", "✨CreateOrPatch"),
+					mergeCommit("be18097a47bdf9341e31a700cc1c2c23ebb48e42", "Merge pull request #1176 from prafull01/multi-apitype", ":sparkles: Add error check for multiple apiTypes as reconciliation object"),
+					mergeCommit("5757a389803ec368126bb1ff046ae3524dacbfcf", "Merge pull request #1155 from DirectXMan12/bug/webhook-server-threadsafe", ":bug: Ensure that webhook server is thread/start-safe"),
+					mergeCommit("ea6a506eb2b74d17606171d46675da4ec4053c5b", "Merge pull request #1075 from alvaroaleman/add", ":running: Proposal to extract cluster-specifics out of the Manager"),
+					mergeCommit("22a2c58a47971ab46c2ff8fab1bf6494632cd1f5", "Merge pull request #1160 from daniel-hutao/patch-1", "update Builder.Register() 's comment - one or more"),
+					mergeCommit("20af9010491c4e97a6d77219d8c22db9b99aa491", "Merge pull request #1163 from vincepri/watches-controller-bug", "🐛 Controller.Watch() should not store watches if already started"),
+					mergeCommit("d6829e9c4db802eb4d5703d22c6cd87e8bbf91da", "Merge pull request #1153 from gogolok/fix_typo", ":book: Fix typo"),
+					mergeCommit("4717461d1f66687d3a82288d3131302d64f11389", "Merge pull request #1144 from alvaroaleman/default-le-resourcelock", "⚠ Change leaderlock from ConfigMap to ConfigMapsLeasesResourceLock"),
+					mergeCommit("be59d6426fe904ea87b348d49503112b8eb5ccef", "Merge pull request #1129 from Shpectator/admission-webhooks-status-response", ":warning: admission responses with raw Status"),
+				}, nil
 			},
 		}
 		currBranch := ReleaseBranch{Version: semver.Version{Minor: 6}}
@@ -151,78 +118,79 @@ Merge pull request #1129 from Shpectator/admission-webhooks-status-response
 			Breaking: []LogEntry{
 				{
 					PRNumber: "1144",
+					Commit:   "4717461d1f66687d3a82288d3131302d64f11389",
 					Title:    "Change leaderlock from ConfigMap to ConfigMapsLeasesResourceLock",
 				},
-				{PRNumber: "1129", Title: "admission responses with raw Status"},
+				{PRNumber: "1129", Commit: "be59d6426fe904ea87b348d49503112b8eb5ccef", Title: "admission responses with raw Status"},
 			},
 			Features: []LogEntry{
-				{PRNumber: "850", Title: "CreateOrPatch"},
+				{PRNumber: "850", Commit: "fdc6658a141b99a3fcb733c8a8000f98e6666f48", Title: "CreateOrPatch"},
 				{
 					PRNumber: "1176",
+					Commit:   "be18097a47bdf9341e31a700cc1c2c23ebb48e42",
 					Title:    "Add error check for multiple apiTypes as reconciliation object",
 				},
 			},
 			Bugs: []LogEntry{
 				{
 					PRNumber: "1155",
+					Commit:   "5757a389803ec368126bb1ff046ae3524dacbfcf",
 					Title:    "Ensure that webhook server is thread/start-safe",
 				},
 				{
 					PRNumber: "1163",
+					Commit:   "20af9010491c4e97a6d77219d8c22db9b99aa491",
 					Title:    "Controller.Watch() should not store watches if already started",
 				},
 			},
 			Docs: []LogEntry{
-				{PRNumber: "1153", Title: "Fix typo"},
+				{PRNumber: "1153", Commit: "d6829e9c4db802eb4d5703d22c6cd87e8bbf91da", Title: "Fix typo"},
 			},
 			Infra: []LogEntry{
-				{PRNumber: "1187", Title: "Update Go mod version to 1.15"},
+				{PRNumber: "1187", Commit: "6af4e7c71d4ca149837d2ed9a33fd8df98ac6103", Title: "Update Go mod version to 1.15"},
 				{
 					PRNumber: "1075",
+					Commit:   "ea6a506eb2b74d17606171d46675da4ec4053c5b",
 					Title:    "Proposal to extract cluster-specifics out of the Manager",
 				},
 			},
 			Uncategorized: []LogEntry{
 				{
 					PRNumber: "1160",
+					Commit:   "22a2c58a47971ab46c2ff8fab1bf6494632cd1f5",
 					Title:    "update Builder.Register() 's comment - one or more",
 				},
 			},
 		}))
 	})
 
-	It("should skip non-GitHub merge commits", func() {
-
+	It("should record the area from the title on changelog entries", func() {
 		gitImpl := gitFuncs{
-			mergeCommitsBetween: func(start, end git.Committish) (string, error) {
-				return (
-				// a few valid commits mixed with some (real) bad merges from CR
-				`commit 06787b6b0e735e5a56fdfbcd8129effaefec3146
-Merge branch 'master' of github.com:bharathi-tenneti/controller-runtime
-
-commit fdc6658a141b99a3fcb733c8a8000f98e6666f48
-Merge pull request #850 from akutz/feature/createOrPatch
-
-✨CreateOrPatch
-commit 334ea25a398a658afac27dc656e9f46893f79c6c
-Merge branch 'upstream-master'
-
-commit 3738249414e4c4e8a2dce2e4328ca5dd00283876
-Merge branch 'master' into k8s-1.15.3
-
-commit ea6a506eb2b74d17606171d46675da4ec4053c5b
-Merge pull request #1075 from alvaroaleman/add
+			mergeCommitsBetween: func(start, end git.Committish) ([]git.CommitRecord, error) {
+				return shortishCommitList, nil
+			},
+		}
+		currBranch := ReleaseBranch{Version: semver.Version{Minor: 6}}
 
-:running: Proposal to extract cluster-specifics out of the Manager
-commit be18097a47bdf9341e31a700cc1c2c23ebb48e42
-Merge pull request #1176 from prafull01/multi-apitype
+		log, err := ChangesSince(gitImpl, currBranch, git.SomeCommittish("abcdef"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(log).To(Equal(shortishChangeLog))
+	})
 
-:sparkles: Add error check for multiple apiTypes as reconciliation object
-commit 5757a389803ec368126bb1ff046ae3524dacbfcf
-Merge pull request #1155 from DirectXMan12/bug/webhook-server-threadsafe
+	It("should skip non-GitHub merge commits", func() {
 
-:bug: Ensure that webhook server is thread/start-safe
-`), nil
+		gitImpl := gitFuncs{
+			mergeCommitsBetween: func(start, end git.Committish) ([]git.CommitRecord, error) {
+				// a few valid commits mixed with some (real) bad merges from CR
+				return []git.CommitRecord{
+					mergeCommit("06787b6b0e735e5a56fdfbcd8129effaefec3146", "Merge branch 'master' of github.com:bharathi-tenneti/controller-runtime", ""),
+					mergeCommit("fdc6658a141b99a3fcb733c8a8000f98e6666f48", "Merge pull request #850 from akutz/feature/createOrPatch", "✨CreateOrPatch"),
+					mergeCommit("334ea25a398a658afac27dc656e9f46893f79c6c", "Merge branch 'upstream-master'", ""),
+					mergeCommit("3738249414e4c4e8a2dce2e4328ca5dd00283876", "Merge branch 'master' into k8s-1.15.3", ""),
+					mergeCommit("ea6a506eb2b74d17606171d46675da4ec4053c5b", "Merge pull request #1075 from alvaroaleman/add", ":running: Proposal to extract cluster-specifics out of the Manager"),
+					mergeCommit("be18097a47bdf9341e31a700cc1c2c23ebb48e42", "Merge pull request #1176 from prafull01/multi-apitype", ":sparkles: Add error check for multiple apiTypes as reconciliation object"),
+					mergeCommit("5757a389803ec368126bb1ff046ae3524dacbfcf", "Merge pull request #1155 from DirectXMan12/bug/webhook-server-threadsafe", ":bug: Ensure that webhook server is thread/start-safe"),
+				}, nil
 			},
 		}
 		currBranch := ReleaseBranch{Version: semver.Version{Minor: 6}}
@@ -231,24 +199,85 @@ Merge pull request #1155 from DirectXMan12/bug/webhook-server-threadsafe
 		Expect(err).NotTo(HaveOccurred())
 		Expect(log).To(Equal(ChangeLog{
 			Features: []LogEntry{
-				{PRNumber: "850", Title: "CreateOrPatch"},
+				{PRNumber: "850", Commit: "fdc6658a141b99a3fcb733c8a8000f98e6666f48", Title: "CreateOrPatch"},
 				{
 					PRNumber: "1176",
+					Commit:   "be18097a47bdf9341e31a700cc1c2c23ebb48e42",
 					Title:    "Add error check for multiple apiTypes as reconciliation object",
 				},
 			},
 			Bugs: []LogEntry{
 				{
 					PRNumber: "1155",
+					Commit:   "5757a389803ec368126bb1ff046ae3524dacbfcf",
 					Title:    "Ensure that webhook server is thread/start-safe",
 				},
 			},
 			Infra: []LogEntry{
 				{
 					PRNumber: "1075",
+					Commit:   "ea6a506eb2b74d17606171d46675da4ec4053c5b",
 					Title:    "Proposal to extract cluster-specifics out of the Manager",
 				},
 			},
 		}))
 	})
+
+	It("should record the merge commit, its author, and when it was merged on changelog entries", func() {
+		merge := mergeCommit("5757a389803ec368126bb1ff046ae3524dacbfcf", "Merge pull request #1155 from DirectXMan12/bug/webhook-server-threadsafe", ":bug: Ensure that webhook server is thread/start-safe")
+		merge.AuthorName = "Vince Prignano"
+		merge.AuthorEmail = "vince@example.com"
+		merge.Date = time.Date(2020, 9, 14, 17, 30, 0, 0, time.UTC)
+		gitImpl := gitFuncs{
+			mergeCommitsBetween: func(start, end git.Committish) ([]git.CommitRecord, error) {
+				return []git.CommitRecord{merge}, nil
+			},
+		}
+		currBranch := ReleaseBranch{Version: semver.Version{Minor: 6}}
+
+		log, err := ChangesSince(gitImpl, currBranch, git.SomeCommittish("abcdef"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(log).To(Equal(ChangeLog{
+			Bugs: []LogEntry{{
+				PRNumber: "1155",
+				Title:    "Ensure that webhook server is thread/start-safe",
+				Commit:   "5757a389803ec368126bb1ff046ae3524dacbfcf",
+				Author:   "Vince Prignano",
+				MergedAt: time.Date(2020, 9, 14, 17, 30, 0, 0, time.UTC),
+			}},
+		}))
+	})
+
+	Context("with a custom taxonomy", func() {
+		AfterEach(func() {
+			common.SetTaxonomy(nil)
+		})
+
+		It("should put releases and custom types into the other changes", func() {
+			taxonomy, err := common.NewTaxonomy(common.TypeInfo{Name: "security", Shortcodes: []string{":lock:"}})
+			Expect(err).NotTo(HaveOccurred())
+			common.SetTaxonomy(taxonomy)
+			security, _ := taxonomy.ByName("security")
+
+			gitImpl := gitFuncs{
+				mergeCommitsBetween: func(start, end git.Committish) ([]git.CommitRecord, error) {
+					return []git.CommitRecord{
+						mergeCommit("06787b6b0e735e5a56fdfbcd8129effaefec3146", "Merge pull request #1200 from vincepri/release-0.7", ":rocket: Release v0.7.0"),
+						mergeCommit("fdc6658a141b99a3fcb733c8a8000f98e6666f48", "Merge pull request #1201 from akutz/feature/redact", ":lock: Redact tokens from logs"),
+					}, nil
+				},
+			}
+			currBranch := ReleaseBranch{Version: semver.Version{Minor: 6}}
+
+			log, err := ChangesSince(gitImpl, currBranch, git.SomeCommittish("abcdef"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(log).To(Equal(ChangeLog{
+				Other: map[common.PRType][]LogEntry{
+					common.ReleasePR: {{PRNumber: "1200", Commit: "06787b6b0e735e5a56fdfbcd8129effaefec3146", Title: "Release v0.7.0"}},
+					security:         {{PRNumber: "1201", Commit: "fdc6658a141b99a3fcb733c8a8000f98e6666f48", Title: "Redact tokens from logs"}},
+				},
+			}))
+			Expect(log.Entries(security)).To(HaveLen(1))
+		})
+	})
 })
@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	golog "log"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+)
+
+// MergeStrategy is the way PRs get merged into a branch, which determines how
+// to find them in the history.
+type MergeStrategy string
+
+const (
+	// MergeStrategyMerge finds PRs from GitHub's `Merge pull request #N from
+	// FORK` merge commits anywhere in the history.  This is the default.
+	MergeStrategyMerge MergeStrategy = "merge"
+	// MergeStrategySquash finds PRs from the single-parent commits made by
+	// GitHub's squash merge, which are titled like `✨ Add thing (#1234)`.
+	MergeStrategySquash MergeStrategy = "squash"
+	// MergeStrategyRebase is like MergeStrategySquash, except that runs of
+	// consecutive commits from the same PR (as made by rebase merges) are
+	// grouped into a single entry.  GitHub's rebase merges don't add the
+	// `(#N)` suffix, so commits without one are looked up using
+	// ReleaseBranch.CommitPRs.
+	MergeStrategyRebase MergeStrategy = "rebase"
+	// MergeStrategyAuto accepts any of the above on a commit-by-commit basis,
+	// for repositories that allow several strategies (or switched between
+	// them).
+	MergeStrategyAuto MergeStrategy = "auto"
+)
+
+// Validate checks that this is a known strategy.  The empty strategy is valid,
+// and means MergeStrategyMerge.
+func (s MergeStrategy) Validate() error {
+	switch s {
+	case "", MergeStrategyMerge, MergeStrategySquash, MergeStrategyRebase, MergeStrategyAuto:
+		return nil
+	default:
+		return fmt.Errorf("unknown merge strategy %q (must be merge|squash|rebase|auto)", string(s))
+	}
+}
+
+// firstParent indicates that this strategy scans the first-parent history
+// instead of looking for merge commits.
+func (s MergeStrategy) firstParent() bool {
+	return s != "" && s != MergeStrategyMerge
+}
+
+var (
+	// mergePRRE matches the title of GitHub's PR merge commits.
+	mergePRRE = regexp.MustCompile(`^Merge pull request #([[:digit:]]+) from \S+$`)
+	// trailingPRRE matches titles with GitHub's trailing PR reference, as
+	// added by squash merges.
+	trailingPRRE = regexp.MustCompile(`^(.*\S)\s+\(#([[:digit:]]+)\)$`)
+)
+
+// prCommit is a commit that came from a PR.
+type prCommit struct {
+	prNumber string
+	title    string
+	// body is the rest of the message of commits made directly on the
+	// branch (which may have footers like `BREAKING CHANGE:`).  It's empty
+	// for merge commits, whose bodies just repeat the PR title.
+	body   string
+	commit git.CommitRecord
+}
+
+// parsedTitle parses the PR title, taking the body into account.
+func (pr prCommit) parsedTitle() common.ParsedTitle {
+	return common.ParseTitleAndBody(pr.title, pr.body)
+}
+
+// prFromMerge figures out which PR the given GitHub merge commit came from,
+// returning false if it's not a GitHub merge commit.  The PR title is the
+// first line of the body.
+func prFromMerge(commit git.CommitRecord) (prCommit, bool) {
+	parts := mergePRRE.FindStringSubmatch(commit.Subject)
+	if parts == nil || commit.Body == "" {
+		return prCommit{}, false
+	}
+	title := strings.SplitN(commit.Body, "\n", 2)[0]
+	return prCommit{prNumber: parts[1], title: title, commit: commit}, true
+}
+
+// prFor figures out which PR the given commit came from according to this
+// strategy, returning false if it doesn't look like it came from one.
+func (s MergeStrategy) prFor(commit git.CommitRecord) (prCommit, bool) {
+	if pr, fromMerge := prFromMerge(commit); fromMerge {
+		return pr, s == MergeStrategyAuto
+	}
+	if parts := trailingPRRE.FindStringSubmatch(commit.Subject); parts != nil {
+		return prCommit{prNumber: parts[2], title: parts[1], body: commit.Body, commit: commit}, true
+	}
+	return prCommit{}, false
+}
+
+// groupRuns groups consecutive commits from the same PR, as made by rebase
+// merges.
+func groupRuns(commits []prCommit) [][]prCommit {
+	var res [][]prCommit
+	for _, commit := range commits {
+		if last := len(res) - 1; last >= 0 && res[last][0].prNumber == commit.prNumber {
+			res[last] = append(res[last], commit)
+			continue
+		}
+		res = append(res, []prCommit{commit})
+	}
+	return res
+}
+
+// forRun combines a run of commits from the same PR (most recent first) into
+// one.  The title is from the oldest commit with a recognized type prefix (or
+// just the oldest one, if none have one), the body combines all of their
+// bodies (so a footer on any of them counts), and the commit is the most
+// recent one, since that's when the PR landed.
+func forRun(run []prCommit) prCommit {
+	res := run[0]
+	res.title = run[len(run)-1].title
+	for i := len(run) - 1; i >= 0; i-- {
+		if run[i].parsedTitle().Type != common.UncategorizedPR {
+			res.title = run[i].title
+			break
+		}
+	}
+	bodies := make([]string, 0, len(run))
+	for i := len(run) - 1; i >= 0; i-- {
+		if run[i].body != "" {
+			bodies = append(bodies, run[i].body)
+		}
+	}
+	res.body = strings.Join(bodies, "\n\n")
+	return res
+}
+
+// lookUpPR finds the PR that the given commit came from using CommitPRs, for
+// commits whose titles don't say (i.e. those from rebase merges), returning
+// false if it didn't come from one, or it can't be looked up.
+func (b ReleaseBranch) lookUpPR(commit git.CommitRecord) (prCommit, bool, error) {
+	if b.CommitPRs == nil || commit.IsMerge() {
+		return prCommit{}, false, nil
+	}
+	if b.MergeStrategy != MergeStrategyRebase && b.MergeStrategy != MergeStrategyAuto {
+		return prCommit{}, false, nil
+	}
+	info, found, err := b.CommitPRs.PullRequestFor(string(commit.SHA))
+	if err != nil {
+		return prCommit{}, false, fmt.Errorf("unable to find the PR that commit %s came from: %w", commit.SHA, err)
+	}
+	if !found {
+		return prCommit{}, false, nil
+	}
+	return prCommit{prNumber: info.Number, title: commit.Subject, body: commit.Body, commit: commit}, true, nil
+}
+
+// firstParentPRsSince finds the PRs merged between the given point and HEAD
+// (most recent first) by scanning the first-parent history of the branch, for
+// strategies other than MergeStrategyMerge.
+func firstParentPRsSince(gitImpl git.Git, branch ReleaseBranch, since git.Committish) ([]prCommit, error) {
+	commits, err := gitImpl.FirstParentCommitsBetween(since, branch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits since %s on branch %q: %w", since.Committish(), branch, err)
+	}
+
+	var prCommits []prCommit
+	for _, commit := range commits {
+		pr, fromPR := branch.MergeStrategy.prFor(commit)
+		if !fromPR {
+			pr, fromPR, err = branch.lookUpPR(commit)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !fromPR {
+			golog.Printf("skipping commit (%q) with title %q, which doesn't look like it came from a PR (merge strategy %q)", commit.SHA, commit.Subject, branch.MergeStrategy)
+			continue
+		}
+		prCommits = append(prCommits, pr)
+	}
+
+	var runs [][]prCommit
+	if branch.MergeStrategy == MergeStrategySquash {
+		for _, pr := range prCommits {
+			runs = append(runs, []prCommit{pr})
+		}
+	} else {
+		runs = groupRuns(prCommits)
+	}
+
+	var res []prCommit
+	elsewhere := 0
+	for _, run := range runs {
+		commits := make([]git.CommitRecord, len(run))
+		for i, pr := range run {
+			commits[i] = pr.commit
+		}
+		touches, err := branch.touchesPaths(gitImpl, commits...)
+		if err != nil {
+			return nil, err
+		}
+		if !touches {
+			elsewhere++
+			continue
+		}
+		res = append(res, forRun(run))
+	}
+	logElsewhere(branch, elsewhere)
+	return res, nil
+}
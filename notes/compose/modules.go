@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	golog "log"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+)
+
+// underPath checks if the given file (relative to the root of the
+// repository) is the given path, or inside it.
+func underPath(file, dir string) bool {
+	dir = path.Clean(dir)
+	if dir == "." || dir == "/" {
+		return true
+	}
+	dir = strings.Trim(dir, "/")
+	return file == dir || strings.HasPrefix(file, dir+"/")
+}
+
+// touchesPaths checks if any of the given commits change something under the
+// branch's Paths (always true if there aren't any).
+func (b ReleaseBranch) touchesPaths(gitImpl git.Git, commits ...git.CommitRecord) (bool, error) {
+	if len(b.Paths) == 0 {
+		return true, nil
+	}
+	for _, commit := range commits {
+		files, err := gitImpl.ChangedFiles(commit.SHA)
+		if err != nil {
+			return false, fmt.Errorf("unable to list the files changed by %s: %w", commit.SHA, err)
+		}
+		for _, file := range files {
+			for _, dir := range b.Paths {
+				if underPath(file, dir) {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// logElsewhere notes how many PRs were skipped for not touching the branch's
+// Paths, if any.
+func logElsewhere(branch ReleaseBranch, skipped int) {
+	if skipped > 0 {
+		golog.Printf("skipping %d PR(s) that don't touch %s", skipped, strings.Join(branch.Paths, ", "))
+	}
+}
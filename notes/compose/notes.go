@@ -17,66 +17,78 @@ limitations under the License.
 package compose
 
 import (
+	"encoding/json"
 	"fmt"
 	golog "log"
-	"regexp"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
 
 	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
 	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/pulls"
 )
 
-var (
-	releaseRE = regexp.MustCompile(`^release-((?:0\.(?P<minor>[[:digit:]]+))|(?P<major>[[:digit:]]+))$`)
-)
-
-// TODO(directxman12): we could use go-git, but it doesn't implement
-// git-describe, which is a pain to implement by hand.
-
-// ReleaseFromBranch extracts a major-ish (X or 0.Y) release given a branch name.
+// ReleaseFromBranch extracts a major-ish (X or 0.Y) release given a branch
+// name, according to KubebuilderBranches.
 func ReleaseFromBranch(branchName string) (ReleaseBranch, error) {
-	parts := releaseRE.FindStringSubmatch(branchName)
-	if parts == nil {
-		return ReleaseBranch{}, fmt.Errorf("%q is not a valid release branch (release-0.Y or release-X)", branchName)
-	}
-	minorRaw := parts[releaseRE.SubexpIndex("minor")]
-	majorRaw := parts[releaseRE.SubexpIndex("major")]
-	switch {
-	case minorRaw != "":
-		minor, err := strconv.ParseUint(minorRaw, 10, 64)
-		if err != nil {
-			return ReleaseBranch{}, fmt.Errorf("could not parse minor version from %q: %w", minorRaw, err)
-		}
-		if minor == 0 {
-			return ReleaseBranch{}, fmt.Errorf("release-0.0 is not a valid release")
-		}
-		return ReleaseBranch{
-			Version: semver.Version{Major: 0, Minor: minor},
-		}, nil
-	case majorRaw != "":
-		major, err := strconv.ParseUint(majorRaw, 10, 64)
-		if err != nil {
-			return ReleaseBranch{}, fmt.Errorf("could not parse major version from %q: %w", majorRaw, err)
-		}
-		if major == 0 {
-			return ReleaseBranch{}, fmt.Errorf("release-0 is not a valid release")
-		}
-		return ReleaseBranch{
-			Version: semver.Version{Major: major},
-		}, nil
-	default:
-		return ReleaseBranch{}, fmt.Errorf("%q is not a valid release branch (release-0.Y or release-X)", branchName)
+	return ParseReleaseBranch(KubebuilderBranches, branchName)
+}
+
+// ParseReleaseBranch extracts the release line covered by the branch with
+// the given name, according to the given scheme.
+func ParseReleaseBranch(scheme BranchScheme, branchName string) (ReleaseBranch, error) {
+	line, err := scheme.Parse(branchName)
+	if err != nil {
+		return ReleaseBranch{}, err
 	}
+	return ReleaseBranch{Version: line, Scheme: scheme}, nil
 }
 
-// ReleaseBranch represents a branch associated with major-ish (X or 0.Y) set
-// of releases.
+// ReleaseBranch represents a branch associated with a release line -- a
+// major-ish (X or 0.Y) set of releases, by default.
 type ReleaseBranch struct {
+	// Version is the release line, as returned by the scheme's Line.
 	semver.Version
+	// Scheme is how release branches are named (defaults to
+	// KubebuilderBranches).
+	Scheme      BranchScheme
 	UseUpstream bool
+	// MergeStrategy is how PRs are merged into this branch, which determines
+	// how to find them when listing changes (defaults to MergeStrategyMerge).
+	MergeStrategy MergeStrategy
+	// CommitPRs, if set, is used to find the PRs that commits came from when
+	// their titles don't say, with MergeStrategyRebase & MergeStrategyAuto,
+	// since GitHub's rebase merges leave commit titles as-is.  Without it,
+	// such commits are skipped.
+	CommitPRs pulls.Source
+	// Paths limits the changes to PRs that touch files under these paths
+	// (relative to the root of the repository), e.g. for a module in a
+	// subdirectory.  If empty, all PRs are included.
+	Paths []string
+	// Shipped is what to do with changes that were already released from
+	// an earlier release line, like fixes cherry-picked into the previous
+	// release branch (defaults to ShippedKeep).
+	Shipped ShippedPolicy
+	// ShippedPRs, if set, is used to read the descriptions of PRs released
+	// from an earlier release line, for references to the PRs they were
+	// cherry-picked from (see Shipped).
+	ShippedPRs pulls.Source
+	// Development is the name of the development branch (like main) to use
+	// in place of a release branch, if set (see DevelopmentBranch).
+	// Development branches aren't part of any one release line, so Version
+	// is ignored, and any release belongs on them.
+	Development string
+	// TagPrefix is prepended to the version in the names of this branch's
+	// release tags, for modules that live in a subdirectory of the
+	// repository and are tagged separately, Go-style (e.g.
+	// "tools/setup-envtest/" for tags like `tools/setup-envtest/v0.1.0`).
+	// The empty prefix, the default, is for the repository's main module.
+	//
+	// Such modules are versioned independently of the release branches, so
+	// any prefixed release belongs on any branch.
+	TagPrefix string
 }
 
 func (b ReleaseBranch) String() string {
@@ -84,33 +96,64 @@ func (b ReleaseBranch) String() string {
 	if b.UseUpstream {
 		upstreamPart = "@{u}"
 	}
-	if b.Major == 0 {
-		return fmt.Sprintf("release-0.%d%s", b.Minor, upstreamPart)
+	if b.IsDevelopment() {
+		return b.Development + upstreamPart
 	}
-	return fmt.Sprintf("release-%d%s", b.Major, upstreamPart)
+	return b.scheme().Format(b.Version) + upstreamPart
+}
+
+// scheme returns the branch's naming scheme, defaulting to
+// KubebuilderBranches.
+func (b ReleaseBranch) scheme() BranchScheme {
+	if b.Scheme == nil {
+		return KubebuilderBranches
+	}
+	return b.Scheme
+}
+
+// forLine returns the branch for another release line, with the same scheme
+// and settings as this one.
+func (b ReleaseBranch) forLine(line semver.Version) ReleaseBranch {
+	res := b
+	res.Version = line
+	return res
 }
 func (b ReleaseBranch) Committish() string {
 	return b.String()
 }
 
+// Local returns the local branch for this one (i.e. without UseUpstream), for
+// referring to it outside of the local repository (like in links).
+func (b ReleaseBranch) Local() ReleaseBranch {
+	res := b
+	res.UseUpstream = false
+	return res
+}
+
 // ReleaseTag is a Committish that's actually a version-tag for a release.
-type ReleaseTag semver.Version
+type ReleaseTag struct {
+	semver.Version
+	// Prefix is prepended to the version in the tag's name (see
+	// ReleaseBranch.TagPrefix).
+	Prefix string
+}
 
 func (v ReleaseTag) Committish() string {
-	return "v" + semver.Version(v).String()
+	return v.Prefix + "v" + v.Version.String()
 }
 func (v ReleaseTag) String() string {
 	return v.Committish()
 }
-func (v ReleaseTag) Validate() error {
-	if len(v.Pre) != 0 && len(v.Pre) != 2 {
-		return fmt.Errorf("invalid pre-release info (must be -{alpha,beta,rc}.version)")
-	}
-	if len(v.Pre) == 2 && (v.Pre[0].IsNum || !v.Pre[1].IsNum) {
-		// TODO: validate alpha, beta, rc
-		return fmt.Errorf("invalid pre-release info (must be -{alpha,beta,rc}.version)")
-	}
-	return nil
+
+// MarshalText marshals this release as its tag name.
+func (v ReleaseTag) MarshalText() ([]byte, error) {
+	return []byte(v.Committish()), nil
+}
+
+// MarshalJSON marshals this release as its tag name, like MarshalText (and
+// unlike the embedded semver.Version, which would leave out the prefix).
+func (v ReleaseTag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Committish())
 }
 
 // FirstCommit is a Committish that's the first commit on a branch, generally
@@ -124,19 +167,18 @@ func (c FirstCommit) Committish() string {
 	return c.Commit.Committish()
 }
 
-// parseReleaseTag parses a git tag name into a ReleaseTag.
-func parseReleaseTag(tagRaw git.Tag) (*ReleaseTag, error) {
-	tagRawBytes := []byte(tagRaw)
-	if tagRawBytes[0] != 'v' {
-		return nil, fmt.Errorf("not a version tag (vX.Y.Z)")
+// parseReleaseTag parses a git tag name (with the given tag prefix) into a
+// ReleaseTag.
+func parseReleaseTag(tagRaw git.Tag, prefix string) (*ReleaseTag, error) {
+	if !strings.HasPrefix(string(tagRaw), prefix+"v") {
+		return nil, fmt.Errorf("not a version tag (%svX.Y.Z)", prefix)
 	}
-	tagRawBytes = tagRawBytes[1:] // skip the 'v'
-
-	tagParsed, err := semver.Parse(string(tagRawBytes))
+	// skip the prefix & the 'v'
+	tagParsed, err := semver.Parse(strings.TrimPrefix(string(tagRaw), prefix+"v"))
 	if err != nil {
 		return nil, err
 	}
-	tag := ReleaseTag(tagParsed)
+	tag := ReleaseTag{Version: tagParsed, Prefix: prefix}
 	if err := tag.Validate(); err != nil {
 		return nil, err
 	}
@@ -144,9 +186,22 @@ func parseReleaseTag(tagRaw git.Tag) (*ReleaseTag, error) {
 }
 
 // LatestRelease returns the most recent ReleaseTag on this branch, or a the
-// FirstCommit if none existed.
+// FirstCommit if none existed.  The most recent release is the highest version
+// out of the release tags merged into the branch that belong to it (or out of
+// all of them, if none belong, e.g. on a freshly-cut release branch), no
+// matter which tag is closest to the head of the branch.
 func (b ReleaseBranch) LatestRelease(gitImpl git.Git, checkVersion bool) (git.Committish, error) {
-	tagRaw, err := gitImpl.ClosestTag(b)
+	tags, err := releaseTagsMergedInto(gitImpl, b, b.TagPrefix)
+	var tag *ReleaseTag
+	if err == nil {
+		tag = highestRelease(tags, func(tag ReleaseTag) bool { return b.VerifyTagBelongs(tag) == nil })
+		if tag == nil {
+			tag = highestRelease(tags, func(ReleaseTag) bool { return true })
+		}
+		if tag == nil {
+			err = fmt.Errorf("no release tags found")
+		}
+	}
 	if err != nil {
 		golog.Printf("unable to get latest tag starting at %q, assuming we need to look for the first commit instead (%v)", b, err)
 		// try to get the first commit
@@ -161,25 +216,24 @@ func (b ReleaseBranch) LatestRelease(gitImpl git.Git, checkVersion bool) (git.Co
 		}, nil
 	}
 
-	tag, err := parseReleaseTag(tagRaw)
-	if err != nil {
-		return nil, fmt.Errorf("latest tag %q on branch %q was not a (valid?) version: %w", tag, b, err)
-	}
-
+	golog.Printf("latest release on branch %q is probably %q", b, tag)
 	if !checkVersion {
-		golog.Printf("latest release on branch %q is probably %q", b, tag)
-		return ReleaseTag(*tag), nil
+		return *tag, nil
 	}
-
-	golog.Printf("latest release on branch %q is probably %q", b, tag)
-	relTag := ReleaseTag(*tag)
-	return relTag, b.VerifyTagBelongs(relTag)
+	return *tag, b.VerifyTagBelongs(*tag)
 }
 
-// VerifyTagBelongs checks that a given tag has the correct major-ish version
-// for this branch.
+// VerifyTagBelongs checks that a given tag is in this branch's release line,
+// and is named with the branch's TagPrefix.  Tags with a prefix always
+// belong, as do all tags on development branches.
 func (b ReleaseBranch) VerifyTagBelongs(tag ReleaseTag) error {
-	if tag.Major != b.Major || (tag.Major == 0 && tag.Minor != b.Minor) {
+	if tag.Prefix != b.TagPrefix {
+		return fmt.Errorf("tag %v is not named like the branch's releases (%svX.Y.Z)", tag, b.TagPrefix)
+	}
+	if b.TagPrefix != "" || b.IsDevelopment() {
+		return nil
+	}
+	if !b.scheme().Line(tag.Version).Equals(b.Version) {
 		return fmt.Errorf("tag's version %v does not match the branch's version %v", tag, b)
 	}
 	return nil
@@ -207,7 +261,9 @@ func checkOrClearUpstream(gitImpl git.Git, branch *ReleaseBranch) {
 // release branch, it'll double-check that branch instead, to get the actual most recent release.
 // For instance, on a fresh `release-0.7` branch, the "latest" release might be `v0.6.0`
 // (since the `v0.Y.0` releases are always off of the main branch), so it'll check `release-0.6`
-// to find that the *actual* latest release is `v0.6.3`.
+// to find that the *actual* latest release is `v0.6.3`.  On development
+// branches, it'll similarly use the latest release overall instead, if that's
+// newer.
 func CurrentVersion(gitImpl git.Git, branch *ReleaseBranch) (git.Committish, error) {
 	origUseUpstream := branch.UseUpstream // keep this around to keep trying later if necessary
 	checkOrClearUpstream(gitImpl, branch)
@@ -216,55 +272,63 @@ func CurrentVersion(gitImpl git.Git, branch *ReleaseBranch) (git.Committish, err
 	if err != nil {
 		return nil, err
 	}
+	if branch.IsDevelopment() {
+		return developmentVersion(gitImpl, *branch, latestHere)
+	}
 
 	tag, isTag := latestHere.(ReleaseTag)
 	if !isTag {
 		golog.Printf("no latest tag, not double-checking version matches")
 		return latestHere, nil
 	}
+	if branch.TagPrefix != "" {
+		// separately-tagged modules don't follow the branch's versions
+		return tag, nil
+	}
 
-	switch {
-	case branch.Major == 0 && tag.Major == 0 && tag.Minor == branch.Minor-1:
-		// most recent tag is a release behind, check the previous branch:
-		// on the first release on a release branch, we'll generally end up
-		// seeing the first release of the last "major-ish" (X, or 0.Y), since
-		// that'll be the only one that ends up on master (the rest are on
-		// a release branch).  Therefore, switch branches backwards to get the actual
-		// last tag.
-		prevRel := ReleaseBranch{
-			Version:     semver.Version{Major: 0, Minor: tag.Minor},
-			UseUpstream: origUseUpstream,
-		}
-		golog.Printf("most recent tag %q is from last version (probably a 0.Y bump), double-checking previous release branch %q for actual latest version", tag.Committish(), prevRel)
-		checkOrClearUpstream(gitImpl, &prevRel)
-		return prevRel.LatestRelease(gitImpl, true)
-	case branch.Major == 1 && tag.Major == 0:
-		// ditto as above, except with 1 releases instead to 0.Y
-		prevRel := ReleaseBranch{
-			Version:     semver.Version{Minor: tag.Minor},
-			UseUpstream: branch.UseUpstream,
-		}
-		golog.Printf("most recent tag %q is from last version (probably a 0.Y --> 1 bump), double-checking previous release branch %q for actual latest version", tag.Committish(), prevRel)
-		checkOrClearUpstream(gitImpl, &prevRel)
-		return prevRel.LatestRelease(gitImpl, true)
-	case branch.Major > 0 && tag.Major == branch.Major-1:
-		// same as the first case, except with X releases instead of 0.Y
-		prevRel := ReleaseBranch{
-			Version:     semver.Version{Major: tag.Major},
-			UseUpstream: branch.UseUpstream,
-		}
-		golog.Printf("most recent tag %q is from last version (probably a X bump), double-checking previous release branch %q for actual latest version", tag.Committish(), prevRel)
-		checkOrClearUpstream(gitImpl, &prevRel)
-		return prevRel.LatestRelease(gitImpl, true)
-	default:
+	tagLine := branch.scheme().Line(tag.Version)
+	if !precedesLine(tagLine, branch.Version) {
 		return tag, branch.VerifyTagBelongs(tag)
 	}
+
+	// most recent tag is from the previous release line, so check that line's
+	// branch: on the first release on a release branch, we'll generally end
+	// up seeing the first release of the previous line (e.g. v0.6.0 for
+	// release-0.7), since that'll be the only one that ends up on master
+	// (the rest are on a release branch).  Therefore, switch branches
+	// backwards to get the actual last tag.
+	prevRel := branch.forLine(tagLine)
+	prevRel.UseUpstream = origUseUpstream
+	golog.Printf("most recent tag %q is from the previous release line, double-checking previous release branch %q for actual latest version", tag.Committish(), prevRel)
+	checkOrClearUpstream(gitImpl, &prevRel)
+	return prevRel.LatestRelease(gitImpl, true)
 }
 
 // LogEntry contains a single changelog entry from a PR.
 type LogEntry struct {
-	PRNumber string
-	Title    string
+	PRNumber string `json:"pr,omitempty"`
+	Title    string `json:"title"`
+	// Area is the part of the project this change touches, if the title
+	// specified one.
+	Area string `json:"area,omitempty"`
+	// Note is a free-form description of the change for users, from the
+	// release-note block in the PR body.  If set, it's used instead of the
+	// title.
+	Note string `json:"note,omitempty"`
+
+	// Commit is the commit that merged the PR (the merge commit, or the
+	// most recent commit for squash & rebase merges).
+	Commit git.Commit `json:"commit,omitempty"`
+	// Author is the author of Commit.  For squash & rebase merges, that's the
+	// author of the PR, but for merge commits, it's whoever merged the PR.
+	Author string `json:"author,omitempty"`
+	// MergedAt is when Commit landed on the branch.
+	MergedAt time.Time `json:"mergedAt"`
+
+	// AlsoIn is the release from an earlier release line that already
+	// shipped an equivalent change (e.g. a cherry-pick of this PR), if the
+	// branch's Shipped policy is ShippedAnnotate.
+	AlsoIn *ReleaseTag `json:"alsoIn,omitempty"`
 }
 
 // ChangeLog holds all changes between a release and HEAD, organized by release type.
@@ -275,15 +339,34 @@ type ChangeLog struct {
 	Docs          []LogEntry
 	Infra         []LogEntry
 	Uncategorized []LogEntry
+
+	// Other holds changes for types without a dedicated field above (releases
+	// and any custom types from the current taxonomy).
+	Other map[common.PRType][]LogEntry
 }
 
-// entryFromCommit adds a changelog entry to this changelog
-// based on the emoji marker in the title.
-func (l *ChangeLog) entryFromCommit(prNum, title string) {
-	entry := LogEntry{PRNumber: prNum}
+// Entries returns the changes of the given type.
+func (l ChangeLog) Entries(prType common.PRType) []LogEntry {
+	switch prType {
+	case common.FeaturePR:
+		return l.Features
+	case common.BugfixPR:
+		return l.Bugs
+	case common.DocsPR:
+		return l.Docs
+	case common.InfraPR:
+		return l.Infra
+	case common.BreakingPR:
+		return l.Breaking
+	case common.UncategorizedPR:
+		return l.Uncategorized
+	default:
+		return l.Other[prType]
+	}
+}
 
-	prType, title := common.PRTypeFromTitle(title)
-	entry.Title = title
+// add appends an entry of the given type to this changelog.
+func (l *ChangeLog) add(prType common.PRType, entry LogEntry) {
 	switch prType {
 	case common.FeaturePR:
 		l.Features = append(l.Features, entry)
@@ -298,20 +381,72 @@ func (l *ChangeLog) entryFromCommit(prNum, title string) {
 	case common.UncategorizedPR:
 		l.Uncategorized = append(l.Uncategorized, entry)
 	default:
-		panic(fmt.Sprintf("unrecognized internal PR type %v", prType))
+		if l.Other == nil {
+			l.Other = make(map[common.PRType][]LogEntry)
+		}
+		l.Other[prType] = append(l.Other[prType], entry)
+	}
+}
+
+// set replaces the entries of the given type in this changelog.
+func (l *ChangeLog) set(prType common.PRType, entries []LogEntry) {
+	switch prType {
+	case common.FeaturePR:
+		l.Features = entries
+	case common.BugfixPR:
+		l.Bugs = entries
+	case common.DocsPR:
+		l.Docs = entries
+	case common.InfraPR:
+		l.Infra = entries
+	case common.BreakingPR:
+		l.Breaking = entries
+	case common.UncategorizedPR:
+		l.Uncategorized = entries
+	default:
+		if len(entries) == 0 {
+			delete(l.Other, prType)
+			return
+		}
+		if l.Other == nil {
+			l.Other = make(map[common.PRType][]LogEntry)
+		}
+		l.Other[prType] = entries
 	}
 }
 
-// ReleaseKind indicates the "finality" of this release -- pre-release (alpha,
-// beta, rc) or final.
-type ReleaseKind int
+// entryFromCommit adds a changelog entry to this changelog
+// based on the emoji marker in the title (or the commit body's
+// footers), noting the earlier release that already shipped it,
+// if any.
+func (l *ChangeLog) entryFromCommit(pr prCommit, alsoIn *ReleaseTag) {
+	parsed := pr.parsedTitle()
+	l.add(parsed.Type, LogEntry{
+		PRNumber: pr.prNumber,
+		Title:    parsed.Title,
+		Area:     parsed.Area,
+		Commit:   pr.commit.SHA,
+		Author:   pr.commit.AuthorName,
+		MergedAt: pr.commit.Date,
+		AlsoIn:   alsoIn,
+	})
+}
 
-const (
-	ReleaseFinal     ReleaseKind = 0
-	ReleaseAlpha     ReleaseKind = 1
-	ReleaseBeta      ReleaseKind = 2
-	ReleaseCandidate ReleaseKind = 3
-)
+// impact computes the largest version bump required by the changes in this
+// changelog, according to the current taxonomy.
+func (l ChangeLog) impact() common.SemverImpact {
+	taxonomy := common.CurrentTaxonomy()
+	res := common.ImpactPatch
+	for _, prType := range taxonomy.Types() {
+		if len(l.Entries(prType)) == 0 {
+			continue
+		}
+		if typeImpact := taxonomy.Info(prType).Impact; typeImpact.Exceeds(res) {
+			res = typeImpact
+		}
+	}
+	return res
+}
 
 // ReleaseInfo describes the desired type of release.
 type ReleaseInfo struct {
@@ -320,6 +455,15 @@ type ReleaseInfo struct {
 	// Pre10 indicates that if the current release is 0.Y, and we'd need a new
 	// major-ish version, choose v0.(Y+1) and not v1.0.0.
 	Pre10 bool
+	// NewLine indicates that the release starts a new release line (it's
+	// being previewed from a development branch), so it bumps at least the
+	// minor version, even if there are only bug fixes.
+	NewLine bool
+	// Initial is the version of the first release, if nothing has been
+	// released yet (defaults to v0.1.0).  It must be a final version that
+	// belongs on the branch; pre-releases of it get the appropriate
+	// pre-release info according to Kind.
+	Initial *ReleaseTag
 }
 
 // ExpectedNextVersion computes what the next version for should be given a set
@@ -333,98 +477,129 @@ type ReleaseInfo struct {
 // - Features bump Y
 // - Anything else just bumps Z
 //
-// If we're jumping between prereleases, ignore all that and either increment
-// the pre-release number or reset the number to zero if we're switching types.
+// If we're jumping between pre-releases, ignore all that and either increment
+// the pre-release number (for the same kind of pre-release), or reset the
+// number to zero (for a later kind).  Going back to an earlier kind of
+// pre-release (e.g. rc to alpha) is a PreReleaseTransitionError.
 //
 // If Pre10 is set, never jump to v1.0.0.
+//
+// See ExplainNextVersion for why a given version was chosen.
 func (c ChangeLog) ExpectedNextVersion(currentVersion git.Committish, info ReleaseInfo) (ReleaseTag, error) {
+	decision, err := c.ExplainNextVersion(currentVersion, info)
+	return decision.Next, err
+}
+
+// ExplainNextVersion computes the next version like ExpectedNextVersion,
+// recording which rule picked it, and which changes triggered that rule.
+func (c ChangeLog) ExplainNextVersion(currentVersion git.Committish, info ReleaseInfo) (VersionDecision, error) {
+	if err := info.Kind.Validate(); err != nil {
+		return VersionDecision{}, err
+	}
+
+	decision := VersionDecision{Current: currentVersion.Committish()}
 	tag, isTag := currentVersion.(ReleaseTag)
 	if !isTag {
-		res := ReleaseTag(semver.Version{
-			Minor: 1,
-		})
-		switch info.Kind {
-		case ReleaseAlpha:
-			res.Pre = []semver.PRVersion{{VersionStr: "alpha"}, {VersionNum: 0, IsNum: true}}
-		case ReleaseBeta:
-			res.Pre = []semver.PRVersion{{VersionStr: "beta"}, {VersionNum: 0, IsNum: true}}
-		case ReleaseCandidate:
-			res.Pre = []semver.PRVersion{{VersionStr: "rc"}, {VersionNum: 0, IsNum: true}}
-		}
-		return res, nil
+		return c.firstVersion(currentVersion, info)
+	}
+	if err := tag.Validate(); err != nil {
+		return VersionDecision{}, fmt.Errorf("current release %s is not valid: %w", tag, err)
 	}
 
-	// final releases
-	newTag := tag
-	if info.Kind == ReleaseFinal {
+	decision.Next = tag
+	switch current := tag.Kind(); {
+	case current == ReleaseFinal:
+		// final --> anything: bump according to rules, then add the
+		// appropriate pre-release info @ 0, if any
+		decision = c.nextFinalVersion(tag, info)
+		decision.Next.Pre = info.Kind.preRelease(0)
+	case info.Kind == ReleaseFinal:
 		// pre --> final: reset pre, keep version
-		if len(newTag.Pre) > 0 {
-			newTag.Pre = nil
-			return newTag, nil
-		}
-
-		// final --> final: bump according to rules
-		return c.nextFinalVersion(tag, info.Pre10), nil
+		decision.Rule = RulePreToFinal
+		decision.Next.Pre = nil
+	case info.Kind == current:
+		// alpha --> alpha || beta --> beta || rc --> rc: increment the number
+		// (with a new Pre, so we don't clobber the old release)
+		decision.Rule = RulePreIncrement
+		decision.Next.Pre = info.Kind.preRelease(tag.Pre[1].VersionNum + 1)
+	case info.Kind > current:
+		// alpha --> beta, etc: keep version, start the new kind @ 0
+		decision.Rule = RulePreAdvance
+		decision.Next.Pre = info.Kind.preRelease(0)
+	default:
+		// rc --> alpha, etc
+		return VersionDecision{}, PreReleaseTransitionError{Current: tag, Kind: info.Kind}
 	}
 
-	// easy pre-release case: same type of pre-release
-	// alpha --> alpha || beta --> beta || rc --> rc
-	wasPre := len(tag.Pre) > 0
-	alphaToAlpha := wasPre && tag.Pre[0] == semver.PRVersion{VersionStr: "alpha"} && info.Kind == ReleaseAlpha
-	betaToBeta := wasPre && tag.Pre[0] == semver.PRVersion{VersionStr: "beta"} && info.Kind == ReleaseBeta
-	candidateToCandidate := wasPre && tag.Pre[0] == semver.PRVersion{VersionStr: "candidate"} && info.Kind == ReleaseCandidate
-	if alphaToAlpha || betaToBeta || candidateToCandidate {
-		newTag := tag
-		// don't clobber old release
-		newTag.Pre = make([]semver.PRVersion, len(tag.Pre))
-		copy(newTag.Pre, tag.Pre)
-		newTag.Pre[1].VersionNum++
-		return newTag, nil
-	}
+	return decision, nil
+}
 
-	// otherwise, if the old release was a final release...
-	if tag.Pre == nil {
-		// ...bump according to rules...
-		newTag = c.nextFinalVersion(tag, info.Pre10)
+// firstVersion computes the version of the first release, made since the
+// given commit (generally a FirstCommit), according to the initial version
+// in info, if any.
+func (c ChangeLog) firstVersion(since git.Committish, info ReleaseInfo) (VersionDecision, error) {
+	decision := VersionDecision{Current: since.Committish()}
+	if info.Initial == nil {
+		decision.Rule = RuleFirstRelease
+		decision.Next = ReleaseTag{Version: semver.Version{
+			Minor: 1,
+			Pre:   info.Kind.preRelease(0),
+		}}
+		if first, isFirst := since.(FirstCommit); isFirst {
+			decision.Next.Prefix = first.Branch.TagPrefix
+		}
+		return decision, nil
 	}
 
-	// ...either way, add the appropriate new pre tag @ 0
-	switch info.Kind {
-	case ReleaseAlpha:
-		newTag.Pre = []semver.PRVersion{{VersionStr: "alpha"}, {VersionNum: 0, IsNum: true}}
-	case ReleaseBeta:
-		newTag.Pre = []semver.PRVersion{{VersionStr: "beta"}, {VersionNum: 0, IsNum: true}}
-	case ReleaseCandidate:
-		newTag.Pre = []semver.PRVersion{{VersionStr: "rc"}, {VersionNum: 0, IsNum: true}}
+	initial := *info.Initial
+	if len(initial.Pre) != 0 {
+		return VersionDecision{}, fmt.Errorf("initial version %s must be a final version (pre-releases of it are made according to the kind of release)", initial)
 	}
-
-	if semver.Version(newTag).LE(semver.Version(tag)) {
-		return newTag, fmt.Errorf("\"new\" version %q actually would be an older version than current %q", newTag.Committish(), tag.Committish())
+	if first, isFirst := since.(FirstCommit); isFirst {
+		if err := first.Branch.VerifyTagBelongs(initial); err != nil {
+			return VersionDecision{}, fmt.Errorf("invalid initial version: %w", err)
+		}
 	}
-
-	return newTag, nil
+	decision.Rule = RuleInitialVersion
+	decision.Next = initial
+	decision.Next.Build = nil
+	decision.Next.Pre = info.Kind.preRelease(0)
+	return decision, nil
 }
 
 // nextFinalVersion computes the next "final" release given the current one and
-// the desired (or lack thereof) to go to v1.0.0.
-func (c ChangeLog) nextFinalVersion(current ReleaseTag, pre10 bool) ReleaseTag {
-	newTag := semver.Version(current)
+// the desired (or lack thereof) to go to v1.0.0 or a new release line.
+func (c ChangeLog) nextFinalVersion(current ReleaseTag, info ReleaseInfo) VersionDecision {
+	newTag := current.Version
 	newTag.Pre = nil
 	newTag.Build = nil
-	switch {
-	case len(c.Breaking) > 0:
-		if current.Major == 0 && pre10 {
+	impact := c.impact()
+	decision := VersionDecision{Current: current.Committish(), Triggers: c.triggers(impact)}
+	if info.NewLine && common.ImpactMinor.Exceeds(impact) {
+		impact = common.ImpactMinor
+		decision.Rule, decision.Triggers = RuleNewLine, nil
+	}
+	switch impact {
+	case common.ImpactMajor:
+		if current.Major == 0 && info.Pre10 {
+			decision.Rule = RulePre10Clamp
 			newTag.IncrementMinor()
 		} else {
+			decision.Rule = RuleBreakingBump
 			newTag.IncrementMajor()
 		}
-	case len(c.Features) > 0:
+	case common.ImpactMinor:
+		if decision.Rule == "" {
+			decision.Rule = RuleFeatureBump
+		}
 		newTag.IncrementMinor()
 	// we're doing a new version anyway, so we probably at least need a patch
 	default:
+		decision.Rule = RulePatchBump
 		newTag.IncrementPatch()
 	}
-	return ReleaseTag(newTag)
+	decision.Next = ReleaseTag{Version: newTag, Prefix: current.Prefix}
+	return decision
 }
 
 // Changes computes the changelog from last release TO HEAD, returning both the
@@ -439,95 +614,77 @@ func Changes(gitImpl git.Git, branch *ReleaseBranch) (log ChangeLog, since git.C
 	return changes, since, err
 }
 
-// ChangesSince computes the changelog from the given point to HEAD.
+// ChangesSince computes the changelog from the given point to HEAD, finding
+// PRs according to the branch's MergeStrategy, and handling ones that were
+// already released from an earlier release line according to its Shipped
+// policy.
 func ChangesSince(gitImpl git.Git, branch ReleaseBranch, since git.Committish) (ChangeLog, error) {
 	golog.Printf("finding changes since %q", since.Committish())
 
-	commitsRaw, err := gitImpl.MergeCommitsBetween(since, branch)
+	var (
+		prs []prCommit
+		err error
+	)
+	if branch.MergeStrategy.firstParent() {
+		prs, err = firstParentPRsSince(gitImpl, branch, since)
+	} else {
+		prs, err = mergePRsSince(gitImpl, branch, since)
+	}
 	if err != nil {
-		return ChangeLog{}, fmt.Errorf("unable to list commits since %s on branch %q: %w", since.Committish(), branch, err)
+		return ChangeLog{}, err
 	}
 
-	log := ChangeLog{}
+	shipped, err := branch.findShipped(gitImpl, since)
+	if err != nil {
+		return ChangeLog{}, err
+	}
 
-	// do this parser-style
-	commitLines := strings.Split(commitsRaw, "\n")
-	lines := &lineReader{lines: commitLines}
-	for lines.more() {
-		var commit, prNumber, fork string
-		if !lines.expectScanf("commit %s", &commit) {
-			// skip terminating blank line, and others
-			// basically, just get to the next known good state
-			if lines.line() != "" {
-				golog.Printf("ignoring seemly non-commit line %q", lines.line())
-			}
-			continue
-		}
-		if !lines.expectScanf("Merge pull request #%s from %s", &prNumber, &fork) {
-			// might be one of the mistakes that got into our history, just
-			// bail till the next commit they look like `Merge branch 'BR'`,
-			// generally
-			golog.Printf("skipping non-official merge commit (%q) with title %q", commit, lines.line())
-			continue
+	log := ChangeLog{}
+	for _, pr := range prs {
+		alsoIn, err := shipped.releaseOf(gitImpl, pr)
+		if err != nil {
+			return ChangeLog{}, err
 		}
-		if !lines.expectBlank() {
-			golog.Printf("got unexpected non-blank line %q, skipping till next commit", lines.line())
+		if alsoIn != nil && branch.Shipped == ShippedDrop {
+			golog.Printf("leaving PR #%s out of the release notes, since it was already released in %s", pr.prNumber, alsoIn)
 			continue
 		}
-		if !lines.next() {
-			break
-		}
-		log.entryFromCommit(prNumber, lines.line())
+		log.entryFromCommit(pr, alsoIn)
 	}
-
 	return log, nil
 }
 
-// lineReader helps parsing line-by-line data, like rev-list output.
-// start by setting lines.
-type lineReader struct {
-	lines []string
-	cur   string
-}
-
-// next loads the next line, returning false if none are available.
-func (l *lineReader) next() bool {
-	if len(l.lines) == 0 {
-		l.cur = ""
-		return false
+// mergePRsSince finds the PRs merged between the given point and HEAD (most
+// recent first) from GitHub's merge commits, for MergeStrategyMerge.
+func mergePRsSince(gitImpl git.Git, branch ReleaseBranch, since git.Committish) ([]prCommit, error) {
+	commits, err := gitImpl.MergeCommitsBetween(since, branch)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits since %s on branch %q: %w", since.Committish(), branch, err)
 	}
-	l.cur = l.lines[0]
-	l.lines = l.lines[1:]
-	return true
-}
 
-// more checks if the next call to next would return true.
-func (l *lineReader) more() bool {
-	return len(l.lines) > 0
-}
-
-// line grabs the current line.
-func (l *lineReader) line() string {
-	return l.cur
-}
-
-// expectScanf loads a new line and scans it according to the supplied args,
-// returning false if it didn't scan or no lines were available.
-func (l *lineReader) expectScanf(fmtStr string, args ...interface{}) bool {
-	if !l.next() {
-		return false
+	var prs []prCommit
+	elsewhere := 0
+	for _, commit := range commits {
+		pr, fromPR := prFromMerge(commit)
+		if !fromPR {
+			// might be one of the mistakes that got into our history, like
+			// `Merge branch 'BR'`, just skip it
+			golog.Printf("skipping non-official merge commit (%q) with title %q", commit.SHA, commit.Subject)
+			continue
+		}
+		touches, err := branch.touchesPaths(gitImpl, commit)
+		if err != nil {
+			return nil, err
+		}
+		if !touches {
+			elsewhere++
+			continue
+		}
+		prs = append(prs, pr)
 	}
-	n, err := fmt.Sscanf(l.cur, fmtStr, args...)
-	return err == nil && n == len(args)
-}
+	logElsewhere(branch, elsewhere)
 
-// expectScanf loads a new line and scans it according to the supplied args,
-// returning false if it's not blank or no lines were available.
-func (l *lineReader) expectBlank() bool {
-	if !l.next() {
-		return false
-	}
-	return l.cur == ""
+	return prs, nil
 }
 
 // IsPreReleaseToFinal figures out if we're going from a pre-release
@@ -546,34 +703,23 @@ func IsPreReleaseToFinal(current git.Committish, next ReleaseTag) bool {
 	return len(tag.Pre) != 0
 }
 
-// ClosestFinal finds the "closest" previous final release.  For example, given
-// `v0.7.0-rc.3`, the closest final release might be `v0.6.3`.
+// ClosestFinal finds the "closest" previous final release: the highest final
+// release reachable from the given one with a lower version (ignoring
+// pre-release info).  For example, given `v0.7.0-rc.3`, the closest final
+// release might be `v0.6.3`.
 func ClosestFinal(gitImpl git.Git, current ReleaseTag) (*ReleaseTag, error) {
-	currentFinal := semver.Version(current)
+	currentFinal := current.Version
 	currentFinal.Pre = nil
 
-	toCheckTag := semver.Version(current)
-	var toCheck git.Committish = current
-	for len(toCheckTag.Pre) != 0 || toCheckTag.EQ(currentFinal) {
-
-		tagRaw, err := gitImpl.ClosestTag(git.SomeCommittish(toCheck.Committish() + "~1"))
-		if err != nil {
-			return nil, err
-		}
-		latestTag, err := parseReleaseTag(tagRaw)
-		if err != nil {
-			golog.Printf("skipping non-release tag %q: %v", string(tagRaw), err)
-			toCheck = git.SomeCommittish(string(tagRaw))
-			continue
-		}
-		toCheck = latestTag
-		toCheckTag = semver.Version(*latestTag)
+	tags, err := releaseTagsMergedInto(gitImpl, current, current.Prefix)
+	if err != nil {
+		return nil, err
 	}
-
-	if toCheckTag.EQ(semver.Version(current)) || len(toCheckTag.Pre) != 0 {
-		return nil, fmt.Errorf("unable to locate previous final release, just found current one")
+	prev := highestRelease(tags, func(tag ReleaseTag) bool {
+		return len(tag.Pre) == 0 && tag.LT(currentFinal)
+	})
+	if prev == nil {
+		return nil, fmt.Errorf("unable to locate a final release before %s", current)
 	}
-
-	tag := ReleaseTag(toCheckTag)
-	return &tag, nil
+	return prev, nil
 }
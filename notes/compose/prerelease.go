@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+
+	"github.com/blang/semver/v4"
+)
+
+// ReleaseKind indicates the "finality" of this release -- pre-release (alpha,
+// beta, rc) or final.  The kinds are ordered: releases of a given version go
+// from alpha, to beta, to rc, to final, possibly skipping some kinds along the
+// way, but never going back.
+type ReleaseKind int
+
+const (
+	ReleaseFinal     ReleaseKind = 0
+	ReleaseAlpha     ReleaseKind = 1
+	ReleaseBeta      ReleaseKind = 2
+	ReleaseCandidate ReleaseKind = 3
+)
+
+// preReleaseIDs are the identifiers that mark each kind of pre-release in
+// versions, like the `rc` in `v1.2.0-rc.3`.
+var preReleaseIDs = map[ReleaseKind]string{
+	ReleaseAlpha:     "alpha",
+	ReleaseBeta:      "beta",
+	ReleaseCandidate: "rc",
+}
+
+// ParseReleaseKind parses a kind of release from its name: "final", or the
+// pre-release identifier ("alpha", "beta", or "rc").
+func ParseReleaseKind(raw string) (ReleaseKind, error) {
+	if raw == "final" {
+		return ReleaseFinal, nil
+	}
+	if kind, isPre := preReleaseKind(raw); isPre {
+		return kind, nil
+	}
+	return ReleaseFinal, fmt.Errorf("unknown release type %q (must be final|alpha|beta|rc)", raw)
+}
+
+// preReleaseKind finds the kind of pre-release with the given identifier.
+func preReleaseKind(id string) (ReleaseKind, bool) {
+	for kind, kindID := range preReleaseIDs {
+		if id == kindID {
+			return kind, true
+		}
+	}
+	return ReleaseFinal, false
+}
+
+// Validate checks that this is a known kind of release.
+func (k ReleaseKind) Validate() error {
+	if _, known := preReleaseIDs[k]; !known && k != ReleaseFinal {
+		return fmt.Errorf("unknown release kind %d", int(k))
+	}
+	return nil
+}
+
+func (k ReleaseKind) String() string {
+	if k == ReleaseFinal {
+		return "final"
+	}
+	if id, known := preReleaseIDs[k]; known {
+		return id
+	}
+	return fmt.Sprintf("ReleaseKind(%d)", int(k))
+}
+
+// preRelease returns the pre-release part of the version for the given
+// release of this kind (e.g. `rc.3`), or nil for final releases.
+func (k ReleaseKind) preRelease(num uint64) []semver.PRVersion {
+	if k == ReleaseFinal {
+		return nil
+	}
+	return []semver.PRVersion{{VersionStr: preReleaseIDs[k]}, {VersionNum: num, IsNum: true}}
+}
+
+// Validate checks that this release is either final, or a numbered alpha,
+// beta, or rc pre-release (like `v1.2.0-rc.3`).
+func (v ReleaseTag) Validate() error {
+	if len(v.Pre) == 0 {
+		return nil
+	}
+	if len(v.Pre) != 2 || v.Pre[0].IsNum || !v.Pre[1].IsNum {
+		return fmt.Errorf("invalid pre-release info (must be -{alpha,beta,rc}.version)")
+	}
+	if _, isPre := preReleaseKind(v.Pre[0].VersionStr); !isPre {
+		return fmt.Errorf("invalid pre-release identifier %q (must be alpha, beta, or rc)", v.Pre[0].VersionStr)
+	}
+	return nil
+}
+
+// Kind returns the kind of this release.  The release must be valid.
+func (v ReleaseTag) Kind() ReleaseKind {
+	if len(v.Pre) == 0 {
+		return ReleaseFinal
+	}
+	kind, _ := preReleaseKind(v.Pre[0].VersionStr)
+	return kind
+}
+
+// PreReleaseTransitionError indicates that a release of the given kind can't
+// follow the current release, since that'd be going back to an earlier kind
+// of pre-release (e.g. from rc to alpha).
+type PreReleaseTransitionError struct {
+	Current ReleaseTag
+	Kind    ReleaseKind
+}
+
+func (e PreReleaseTransitionError) Error() string {
+	return fmt.Sprintf("%s releases cannot follow %s (pre-releases go from alpha, to beta, to rc, and never back)", e.Kind, e.Current)
+}
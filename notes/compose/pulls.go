@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	golog "log"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/pulls"
+)
+
+// CategorizeFromLabels tries to categorize the Uncategorized entries in this
+// changelog using the labels on their PRs, according to the current taxonomy.
+// Entries whose labels don't indicate a type are left as-is.
+func (l *ChangeLog) CategorizeFromLabels(prs pulls.Source) error {
+	uncategorized := l.Uncategorized
+	l.Uncategorized = nil
+	for i, entry := range uncategorized {
+		if entry.PRNumber == "" {
+			l.Uncategorized = append(l.Uncategorized, entry)
+			continue
+		}
+
+		info, err := prs.PullRequest(entry.PRNumber)
+		if err != nil {
+			// don't lose the rest of the entries
+			l.Uncategorized = append(l.Uncategorized, uncategorized[i:]...)
+			return fmt.Errorf("unable to fetch labels for uncategorized PR #%s: %w", entry.PRNumber, err)
+		}
+
+		prType, label, found := common.PRTypeFromLabels(info.Labels)
+		if !found {
+			l.Uncategorized = append(l.Uncategorized, entry)
+			continue
+		}
+		golog.Printf("categorizing PR #%s as %s based on label %q", entry.PRNumber, prType, label)
+		l.add(prType, entry)
+	}
+	return nil
+}
+
+// ApplyReleaseNotes fills in the Note of each entry from the release-note
+// block in its PR's body, if any.  Entries whose note is common.NoReleaseNote
+// are removed from the changelog.  Malformed blocks are logged and ignored.
+func (l *ChangeLog) ApplyReleaseNotes(prs pulls.Source) error {
+	for _, prType := range common.CurrentTaxonomy().Types() {
+		entries := l.Entries(prType)
+		var kept []LogEntry
+		for i, entry := range entries {
+			if entry.PRNumber == "" {
+				kept = append(kept, entry)
+				continue
+			}
+
+			info, err := prs.PullRequest(entry.PRNumber)
+			if err != nil {
+				// don't lose the rest of the entries
+				l.set(prType, append(kept, entries[i:]...))
+				return fmt.Errorf("unable to fetch body of PR #%s: %w", entry.PRNumber, err)
+			}
+
+			note, found, err := common.ReleaseNoteFromBody(info.Body)
+			switch {
+			case err != nil:
+				golog.Printf("ignoring release note for PR #%s: %v", entry.PRNumber, err)
+			case found && note == common.NoReleaseNote:
+				golog.Printf("leaving PR #%s out of the release notes, as requested", entry.PRNumber)
+				continue
+			case found:
+				entry.Note = note
+			}
+			kept = append(kept, entry)
+		}
+		l.set(prType, kept)
+	}
+	return nil
+}
+
+// PromoteBreaking moves entries whose PR bodies contain an explicit
+// breaking-change marker (see common.BreakingMarker) to Breaking, unless
+// their type already requires a major version bump.  This way, a mislabeled
+// PR can't cause the version to be under-bumped.
+func (l *ChangeLog) PromoteBreaking(prs pulls.Source) error {
+	taxonomy := common.CurrentTaxonomy()
+	for _, prType := range taxonomy.Types() {
+		if taxonomy.Info(prType).Impact == common.ImpactMajor {
+			continue
+		}
+		entries := l.Entries(prType)
+		var kept []LogEntry
+		for i, entry := range entries {
+			if entry.PRNumber == "" {
+				kept = append(kept, entry)
+				continue
+			}
+
+			info, err := prs.PullRequest(entry.PRNumber)
+			if err != nil {
+				// don't lose the rest of the entries
+				l.set(prType, append(kept, entries[i:]...))
+				return fmt.Errorf("unable to fetch body of PR #%s: %w", entry.PRNumber, err)
+			}
+
+			if marker := common.BreakingMarker(info.Body); marker != "" {
+				golog.Printf("treating %s PR #%s as breaking, since its description contains %q", prType, entry.PRNumber, marker)
+				l.add(common.BreakingPR, entry)
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		l.set(prType, kept)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose_test
+
+import (
+	"github.com/blang/semver/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/compose"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/pulls"
+)
+
+var _ = Describe("Information from PRs", func() {
+	Describe("categorizing from labels", func() {
+		It("should move uncategorized entries with known labels to the right type", func() {
+			log := ChangeLog{
+				Bugs: []LogEntry{{PRNumber: "1155", Title: "Ensure that webhook server is thread/start-safe"}},
+				Uncategorized: []LogEntry{
+					{PRNumber: "1160", Title: "update Builder.Register() 's comment - one or more"},
+					{PRNumber: "1161", Title: "Fix leaking goroutine"},
+					{PRNumber: "1162", Title: "Add Foo field"},
+					{Title: "some non-PR change"},
+				},
+			}
+			prs := fakePulls{
+				"1160": {Number: "1160", Labels: []string{"kind/documentation", "lgtm"}},
+				"1161": {Number: "1161", Labels: []string{"kind/bug"}},
+				"1162": {Number: "1162", Labels: []string{"kind/bug", "kind/api-change"}},
+			}
+
+			Expect(log.CategorizeFromLabels(prs)).To(Succeed())
+			Expect(log).To(Equal(ChangeLog{
+				Features: []LogEntry{{PRNumber: "1162", Title: "Add Foo field"}},
+				Bugs: []LogEntry{
+					{PRNumber: "1155", Title: "Ensure that webhook server is thread/start-safe"},
+					{PRNumber: "1161", Title: "Fix leaking goroutine"},
+				},
+				Docs: []LogEntry{{PRNumber: "1160", Title: "update Builder.Register() 's comment - one or more"}},
+				Uncategorized: []LogEntry{
+					{Title: "some non-PR change"},
+				},
+			}))
+		})
+
+		It("should leave entries without known labels uncategorized", func() {
+			log := ChangeLog{
+				Uncategorized: []LogEntry{{PRNumber: "1160", Title: "update the comment"}},
+			}
+			prs := fakePulls{"1160": {Number: "1160", Labels: []string{"lgtm", "approved"}}}
+
+			Expect(log.CategorizeFromLabels(prs)).To(Succeed())
+			Expect(log).To(Equal(ChangeLog{
+				Uncategorized: []LogEntry{{PRNumber: "1160", Title: "update the comment"}},
+			}))
+		})
+
+		It("should keep all remaining entries if fetching a PR fails", func() {
+			log := ChangeLog{
+				Uncategorized: []LogEntry{
+					{PRNumber: "1160", Title: "update the comment"},
+					{PRNumber: "1161", Title: "Fix leaking goroutine"},
+				},
+			}
+			prs := fakePulls{"1161": {Number: "1161", Labels: []string{"kind/bug"}}}
+
+			Expect(log.CategorizeFromLabels(prs)).NotTo(Succeed())
+			Expect(log.Uncategorized).To(HaveLen(2))
+		})
+	})
+
+	Describe("applying release notes", func() {
+		It("should use release-note blocks from PR bodies as notes", func() {
+			log := ChangeLog{
+				Features: []LogEntry{{PRNumber: "1162", Title: "Add Foo field"}},
+				Bugs: []LogEntry{
+					{PRNumber: "1155", Title: "Ensure that webhook server is thread/start-safe"},
+					{PRNumber: "1161", Title: "Fix leaking goroutine"},
+				},
+				Uncategorized: []LogEntry{{Title: "some non-PR change"}},
+			}
+			prs := fakePulls{
+				"1155": {Number: "1155", Body: "```release-note\nThe webhook server may now be started more than once.\n```"},
+				"1161": {Number: "1161", Body: "Fixes #1160"},
+				"1162": {Number: "1162", Body: "```release-note\nNONE\n```"},
+			}
+
+			Expect(log.ApplyReleaseNotes(prs)).To(Succeed())
+			Expect(log).To(Equal(ChangeLog{
+				Bugs: []LogEntry{
+					{PRNumber: "1155", Title: "Ensure that webhook server is thread/start-safe", Note: "The webhook server may now be started more than once."},
+					{PRNumber: "1161", Title: "Fix leaking goroutine"},
+				},
+				Uncategorized: []LogEntry{{Title: "some non-PR change"}},
+			}))
+		})
+
+		It("should ignore malformed release-note blocks", func() {
+			log := ChangeLog{
+				Bugs: []LogEntry{{PRNumber: "1161", Title: "Fix leaking goroutine"}},
+			}
+			prs := fakePulls{"1161": {Number: "1161", Body: "```release-note\nFix leaking goroutine"}}
+
+			Expect(log.ApplyReleaseNotes(prs)).To(Succeed())
+			Expect(log).To(Equal(ChangeLog{
+				Bugs: []LogEntry{{PRNumber: "1161", Title: "Fix leaking goroutine"}},
+			}))
+		})
+
+		It("should keep all remaining entries if fetching a PR fails", func() {
+			log := ChangeLog{
+				Bugs: []LogEntry{
+					{PRNumber: "1155", Title: "Ensure that webhook server is thread/start-safe"},
+					{PRNumber: "1161", Title: "Fix leaking goroutine"},
+				},
+			}
+			prs := fakePulls{"1155": {Number: "1155", Body: "```release-note\nNONE\n```"}}
+
+			Expect(log.ApplyReleaseNotes(prs)).NotTo(Succeed())
+			Expect(log.Bugs).To(Equal([]LogEntry{{PRNumber: "1161", Title: "Fix leaking goroutine"}}))
+		})
+	})
+
+	Describe("promoting breaking changes", func() {
+		It("should move entries with breaking markers in their PR bodies to breaking", func() {
+			log := ChangeLog{
+				Breaking: []LogEntry{{PRNumber: "1150", Title: "Remove the old client"}},
+				Features: []LogEntry{
+					{PRNumber: "1162", Title: "Add Foo field"},
+					{PRNumber: "1163", Title: "Switch to leases"},
+				},
+				Bugs: []LogEntry{{PRNumber: "1161", Title: "Fix leaking goroutine"}},
+			}
+			prs := fakePulls{
+				"1161": {Number: "1161", Body: "## Action required\n\nRe-run the migration."},
+				"1162": {Number: "1162", Body: "Adds a field.\n<!-- BREAKING CHANGE: describe breaking changes here -->"},
+				"1163": {Number: "1163", Body: "Switches the lock.\n\nBREAKING CHANGE: ConfigMap locks are gone"},
+			}
+
+			Expect(log.PromoteBreaking(prs)).To(Succeed())
+			Expect(log).To(Equal(ChangeLog{
+				Breaking: []LogEntry{
+					{PRNumber: "1150", Title: "Remove the old client"},
+					{PRNumber: "1163", Title: "Switch to leases"},
+					{PRNumber: "1161", Title: "Fix leaking goroutine"},
+				},
+				Features: []LogEntry{{PRNumber: "1162", Title: "Add Foo field"}},
+			}))
+		})
+
+		It("should cause a major-ish version bump", func() {
+			log := ChangeLog{
+				Features: []LogEntry{{PRNumber: "1163", Title: "Switch to leases"}},
+			}
+			prs := fakePulls{
+				"1163": {Number: "1163", Body: "```release-note\nACTION REQUIRED: use leases instead of ConfigMaps\n```"},
+			}
+
+			Expect(log.PromoteBreaking(prs)).To(Succeed())
+			Expect(log.ExpectedNextVersion(ReleaseTag{Version: semver.MustParse("0.6.3")}, ReleaseInfo{Pre10: true})).To(Equal(ReleaseTag{Version: semver.MustParse("0.7.0")}))
+		})
+	})
+})
+
+var _ pulls.Source = fakePulls{}
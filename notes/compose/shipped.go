@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	golog "log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/pulls"
+)
+
+// ShippedPolicy is what ChangesSince does with changes that were already
+// released from an earlier release line.  For instance, when v0.7.0 is cut
+// from main, fixes that were cherry-picked into v0.6.x patch releases would
+// otherwise be listed again.
+type ShippedPolicy string
+
+const (
+	// ShippedKeep lists such changes like any other, without looking for
+	// them.  This is the default.
+	ShippedKeep ShippedPolicy = "keep"
+	// ShippedAnnotate lists such changes along with the release that
+	// already shipped them (see LogEntry.AlsoIn).
+	ShippedAnnotate ShippedPolicy = "annotate"
+	// ShippedDrop leaves such changes out of the changelog entirely.
+	ShippedDrop ShippedPolicy = "drop"
+)
+
+// Validate checks that this is a known policy.  The empty policy is valid,
+// and means ShippedKeep.
+func (p ShippedPolicy) Validate() error {
+	switch p {
+	case "", ShippedKeep, ShippedAnnotate, ShippedDrop:
+		return nil
+	default:
+		return fmt.Errorf("unknown policy for already-released changes %q (must be keep|annotate|drop)", string(p))
+	}
+}
+
+var (
+	// cherryPickedFromRE matches the line `git cherry-pick -x` adds to
+	// commit messages.
+	cherryPickedFromRE = regexp.MustCompile(`\(cherry picked from commit ([[:xdigit:]]{40})\)`)
+	// cherryPickOfRE matches references to the PR(s) that a PR was
+	// cherry-picked from, like "This is an automated cherry-pick of #1234"
+	// or "Cherry pick of #1234 #1235 on release-0.6".
+	cherryPickOfRE = regexp.MustCompile(`(?i)\b(?:cherry[- ]?pick(?:ed)?|backport(?:ed)?)\s+(?:of|from)\s+((?:#[[:digit:]]+[,\s]*)+)`)
+	// prRefRE matches a single PR reference.
+	prRefRE = regexp.MustCompile(`#([[:digit:]]+)`)
+)
+
+// cherryPickedPRs lists the PRs that the given text says it was cherry-picked
+// from (see cherryPickOfRE).
+func cherryPickedPRs(text string) []string {
+	var res []string
+	for _, match := range cherryPickOfRE.FindAllStringSubmatch(text, -1) {
+		for _, ref := range prRefRE.FindAllStringSubmatch(match[1], -1) {
+			res = append(res, ref[1])
+		}
+	}
+	return res
+}
+
+// mergedCommits lists the given commit, along with the commits it merged, if
+// it's a merge commit (i.e. the commits made on a PR's branch).
+func mergedCommits(gitImpl git.Git, commit git.CommitRecord) ([]git.CommitRecord, error) {
+	if !commit.IsMerge() {
+		return []git.CommitRecord{commit}, nil
+	}
+	merged, err := gitImpl.FirstParentCommitsBetween(commit.Parents[0], commit.Parents[1])
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the commits merged by %s: %w", commit.SHA, err)
+	}
+	return append([]git.CommitRecord{commit}, merged...), nil
+}
+
+// shippedIndex records which releases from an earlier release line shipped
+// which changes, by each of the ways that an equivalent change can be
+// recognized.  The first (lowest) release recorded for each one wins.
+type shippedIndex struct {
+	// byPR holds the PRs that were cherry-picked, according to the
+	// descriptions & messages of the cherry-picks.
+	byPR map[string]ReleaseTag
+	// byCommit holds the commits that were cherry-picked, according to
+	// `(cherry picked from commit ...)` lines.
+	byCommit map[git.Commit]ReleaseTag
+	// byPatchID holds the patch IDs (see git.Git.PatchID) of the changes
+	// that were released.
+	byPatchID map[string]ReleaseTag
+}
+
+// add records the changes made by the given commit (and whatever it merged)
+// as shipped in the given release, checking the description of the PR it
+// came from, if any, using prs (if set).
+func (i *shippedIndex) add(gitImpl git.Git, prs pulls.Source, commit git.CommitRecord, release ReleaseTag) error {
+	var prRefs []string
+	if pr, fromPR := MergeStrategyAuto.prFor(commit); fromPR && prs != nil {
+		info, err := prs.PullRequest(pr.prNumber)
+		if err != nil {
+			golog.Printf("unable to check the description of PR #%s for cherry-picked PRs, skipping it: %v", pr.prNumber, err)
+		} else {
+			prRefs = cherryPickedPRs(info.Body)
+		}
+	}
+
+	commits, err := mergedCommits(gitImpl, commit)
+	if err != nil {
+		return err
+	}
+	for _, commit := range commits {
+		prRefs = append(prRefs, cherryPickedPRs(commit.Subject+"\n"+commit.Body)...)
+		for _, match := range cherryPickedFromRE.FindAllStringSubmatch(commit.Body, -1) {
+			if _, known := i.byCommit[git.Commit(match[1])]; !known {
+				i.byCommit[git.Commit(match[1])] = release
+			}
+		}
+
+		id, err := gitImpl.PatchID(commit.SHA)
+		if err != nil {
+			return fmt.Errorf("unable to compute the patch ID of %s: %w", commit.SHA, err)
+		}
+		if _, known := i.byPatchID[id]; id != "" && !known {
+			i.byPatchID[id] = release
+		}
+	}
+	for _, prNumber := range prRefs {
+		if _, known := i.byPR[prNumber]; !known {
+			i.byPR[prNumber] = release
+		}
+	}
+	return nil
+}
+
+// releaseOf returns the release that already shipped an equivalent of the
+// given PR, or nil if there isn't one (or the index is nil, because nothing
+// was looked up).
+func (i *shippedIndex) releaseOf(gitImpl git.Git, pr prCommit) (*ReleaseTag, error) {
+	if i == nil {
+		return nil, nil
+	}
+	if release, found := i.byPR[pr.prNumber]; found && pr.prNumber != "" {
+		return &release, nil
+	}
+
+	if len(i.byCommit) > 0 {
+		commits, err := mergedCommits(gitImpl, pr.commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range commits {
+			if release, found := i.byCommit[commit.SHA]; found {
+				return &release, nil
+			}
+		}
+	}
+
+	if len(i.byPatchID) > 0 {
+		id, err := gitImpl.PatchID(pr.commit.SHA)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compute the patch ID of %s: %w", pr.commit.SHA, err)
+		}
+		if release, found := i.byPatchID[id]; found && id != "" {
+			return &release, nil
+		}
+	}
+	return nil, nil
+}
+
+// shippedReleases lists the releases contained in since that aren't on this
+// branch (i.e. when since is the latest release from an earlier release line,
+// the releases made on that line after this branch split off), lowest first.
+func (b ReleaseBranch) shippedReleases(gitImpl git.Git, since git.Committish) ([]ReleaseTag, error) {
+	onBranch, err := releaseTagsMergedInto(gitImpl, b, b.TagPrefix)
+	if err != nil {
+		return nil, err
+	}
+	contained := make(map[string]bool, len(onBranch))
+	for _, tag := range onBranch {
+		contained[tag.String()] = true
+	}
+
+	inSince, err := releaseTagsMergedInto(gitImpl, since, b.TagPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var res []ReleaseTag
+	for _, tag := range inSince {
+		if !contained[tag.String()] {
+			res = append(res, tag)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LT(res[j].Version)
+	})
+	return res, nil
+}
+
+// findShipped indexes the changes that were released from an earlier release
+// line in the releases contained in since, according to this branch's
+// Shipped policy.  It returns a nil index if there's nothing to look for.
+func (b ReleaseBranch) findShipped(gitImpl git.Git, since git.Committish) (*shippedIndex, error) {
+	if b.Shipped == "" || b.Shipped == ShippedKeep {
+		return nil, nil
+	}
+	releases, err := b.shippedReleases(gitImpl, since)
+	if err != nil || len(releases) == 0 {
+		return nil, err
+	}
+	names := make([]string, len(releases))
+	for i, release := range releases {
+		names[i] = release.String()
+	}
+	golog.Printf("checking for changes already released in %s", strings.Join(names, ", "))
+
+	index := &shippedIndex{
+		byPR:      make(map[string]ReleaseTag),
+		byCommit:  make(map[git.Commit]ReleaseTag),
+		byPatchID: make(map[string]ReleaseTag),
+	}
+	seen := make(map[git.Commit]bool)
+	for _, release := range releases {
+		commits, err := gitImpl.FirstParentCommitsBetween(b, release)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list commits in %s that aren't on branch %q: %w", release, b, err)
+		}
+		for _, commit := range commits {
+			if seen[commit.SHA] {
+				continue
+			}
+			seen[commit.SHA] = true
+			if err := index.add(gitImpl, b.ShippedPRs, commit, release); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return index, nil
+}
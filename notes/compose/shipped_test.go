@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose_test
+
+import (
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/compose"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git/gittest"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/pulls"
+)
+
+var _ = Describe("Changes already released from an earlier release line", func() {
+	var (
+		repo       *gittest.Repo
+		main, rel6 *gittest.Branch
+		prs        fakePulls
+	)
+	v0_6_1 := ReleaseTag{Version: semver.MustParse("0.6.1")}
+	v0_6_2 := ReleaseTag{Version: semver.MustParse("0.6.2")}
+	BeforeEach(func() {
+		// main:        init -- #1100 (v0.6.0) -- #1160 -- #1162 -- #1163 -- #1164 -- #1165
+		//                          \
+		// release-0.6:              #1170 (v0.6.1) -- #1171 -- picked #1163 (v0.6.2)
+		//
+		// #1170 is a cherry-pick of #1160 according to its description, #1171
+		// merges a `git cherry-pick -x` of #1162 (with different changes, as
+		// if it had conflicts), and #1163 was cherry-picked directly.
+		repo = gittest.NewRepo()
+		main = repo.Branch("main")
+		main.Commit("Initial commit")
+		main.Changing("webhook.go").MergePR(1100, ":sparkles: Add the webhook server")
+		main.Tag("v0.6.0")
+		rel6 = main.Fork("release-0.6")
+
+		main.Changing("manager.go").MergePR(1160, ":bug: Fix leaking goroutine")
+		main.Changing("webhook.go").MergePR(1162, ":bug: Fix webhook registration")
+		main.Changing("cache.go").MergePR(1163, ":bug: Fix cache sync")
+		main.Changing("client.go").MergePR(1164, ":sparkles: Add Foo field")
+		main.Changing("manager.go").MergePR(1165, ":bug: Fix another leak")
+
+		rel6.Changing("manager.go").MergePR(1170, "[release-0.6] :bug: Fix leaking goroutine")
+		rel6.Tag("v0.6.1")
+		pick := rel6.Fork("pick-1162")
+		pick.Changing("webhook.go").Commit(fmt.Sprintf(":bug: Fix webhook registration\n\n(cherry picked from commit %s)", repo.Commit(":bug: Fix webhook registration")))
+		rel6.Merge(pick, "Merge pull request #1171 from someone/pick-1162\n\n[release-0.6] :bug: Fix webhook registration")
+		rel6.CherryPick(repo.Commit("Merge pull request #1163 from someone/pr-1163\n\n:bug: Fix cache sync"))
+		rel6.Tag("v0.6.2")
+
+		prs = fakePulls{
+			"1170": {Body: "This is an automated cherry-pick of #1160\n\n/assign someone"},
+			"1171": {Body: "Backported by hand, since it didn't apply cleanly."},
+		}
+	})
+
+	mainBranch := func(policy ShippedPolicy, shippedPRs pulls.Source) ReleaseBranch {
+		branch := DevelopmentBranch("main")
+		branch.Shipped = policy
+		branch.ShippedPRs = shippedPRs
+		return branch
+	}
+
+	It("should list them like any other change by default", func() {
+		log, err := ChangesSince(repo, mainBranch("", prs), v0_6_2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutCommits(log)).To(Equal(ChangeLog{
+			Features: []LogEntry{{PRNumber: "1164", Title: "Add Foo field"}},
+			Bugs: []LogEntry{
+				{PRNumber: "1165", Title: "Fix another leak"},
+				{PRNumber: "1163", Title: "Fix cache sync"},
+				{PRNumber: "1162", Title: "Fix webhook registration"},
+				{PRNumber: "1160", Title: "Fix leaking goroutine"},
+			},
+		}))
+	})
+
+	It("should annotate them with the first release that shipped them, however they were cherry-picked", func() {
+		log, err := ChangesSince(repo, mainBranch(ShippedAnnotate, prs), v0_6_2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutCommits(log)).To(Equal(ChangeLog{
+			Features: []LogEntry{{PRNumber: "1164", Title: "Add Foo field"}},
+			Bugs: []LogEntry{
+				{PRNumber: "1165", Title: "Fix another leak"},
+				{PRNumber: "1163", Title: "Fix cache sync", AlsoIn: &v0_6_2},
+				{PRNumber: "1162", Title: "Fix webhook registration", AlsoIn: &v0_6_2},
+				{PRNumber: "1160", Title: "Fix leaking goroutine", AlsoIn: &v0_6_1},
+			},
+		}))
+	})
+
+	It("should drop them if requested", func() {
+		log, err := ChangesSince(repo, mainBranch(ShippedDrop, prs), v0_6_2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutCommits(log)).To(Equal(ChangeLog{
+			Features: []LogEntry{{PRNumber: "1164", Title: "Add Foo field"}},
+			Bugs:     []LogEntry{{PRNumber: "1165", Title: "Fix another leak"}},
+		}))
+	})
+
+	It("should only recognize cherry-picks from PR descriptions if it can read them", func() {
+		log, err := ChangesSince(repo, mainBranch(ShippedDrop, nil), v0_6_2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutCommits(log).Bugs).To(Equal([]LogEntry{
+			{PRNumber: "1165", Title: "Fix another leak"},
+			{PRNumber: "1160", Title: "Fix leaking goroutine"},
+		}))
+	})
+
+	It("should check new release branches against the previous one", func() {
+		main.Fork("release-0.7")
+		branch := ReleaseBranch{Version: semver.Version{Minor: 7}, Shipped: ShippedDrop, ShippedPRs: prs}
+		since, err := CurrentVersion(repo, &branch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(since).To(Equal(v0_6_2))
+
+		log, err := ChangesSince(repo, branch, since)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutCommits(log).Bugs).To(Equal([]LogEntry{{PRNumber: "1165", Title: "Fix another leak"}}))
+	})
+
+	It("should not consider releases from the branch itself to be earlier ones", func() {
+		branch := ReleaseBranch{Version: semver.Version{Minor: 6}, Shipped: ShippedDrop, ShippedPRs: prs}
+		log, err := ChangesSince(repo, branch, v0_6_1)
+		Expect(err).NotTo(HaveOccurred())
+		title := common.ParseTitle("[release-0.6] :bug: Fix webhook registration")
+		Expect(withoutCommits(log).Entries(title.Type)).To(Equal([]LogEntry{{PRNumber: "1171", Title: title.Title}}))
+	})
+
+	It("should recognize cherry-picks of squash merges", func() {
+		repo = gittest.NewRepo()
+		main = repo.Branch("main")
+		main.Commit("Initial commit")
+		main.Tag("v0.6.0")
+		rel6 = main.Fork("release-0.6")
+		fix := main.Changing("manager.go").SquashPR(1160, ":bug: Fix leaking goroutine")
+		rel6.CherryPickX(fix)
+		rel6.Tag("v0.6.1")
+
+		branch := DevelopmentBranch("main")
+		branch.MergeStrategy = MergeStrategySquash
+		branch.Shipped = ShippedAnnotate
+		log, err := ChangesSince(repo, branch, v0_6_1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(withoutCommits(log)).To(Equal(ChangeLog{
+			Bugs: []LogEntry{{PRNumber: "1160", Title: "Fix leaking goroutine", AlsoIn: &v0_6_1}},
+		}))
+	})
+
+	It("should reject unknown policies", func() {
+		Expect(ShippedAnnotate.Validate()).To(Succeed())
+		Expect(ShippedPolicy("").Validate()).To(Succeed())
+		Expect(ShippedPolicy("hide").Validate()).To(MatchError(ContainSubstring("must be keep|annotate|drop")))
+	})
+})
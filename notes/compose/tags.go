@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	golog "log"
+	"strings"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+)
+
+// parseReleaseTags parses the release tags (with the given tag prefix) out of
+// the given tags.  Tags that aren't named like versions (e.g. `latest`, or
+// ones for other modules) are ignored, and ones that are, but aren't valid
+// release versions (e.g. `v0.1`), are reported & skipped.
+func parseReleaseTags(tagsRaw []git.Tag, prefix string) []ReleaseTag {
+	var res []ReleaseTag
+	for _, tagRaw := range tagsRaw {
+		if !strings.HasPrefix(string(tagRaw), prefix+"v") {
+			continue
+		}
+		tag, err := parseReleaseTag(tagRaw, prefix)
+		if err != nil {
+			golog.Printf("skipping non-release tag %q: %v", string(tagRaw), err)
+			continue
+		}
+		res = append(res, *tag)
+	}
+	return res
+}
+
+// releaseTagsMergedInto lists the release tags with the given prefix that are
+// reachable from the given committish (see parseReleaseTags).
+func releaseTagsMergedInto(gitImpl git.Git, into git.Committish, prefix string) ([]ReleaseTag, error) {
+	tagsRaw, err := gitImpl.MergedTags(into)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tags merged into %q: %w", into.Committish(), err)
+	}
+	return parseReleaseTags(tagsRaw, prefix), nil
+}
+
+// highestRelease returns the highest version out of the given releases that
+// are accepted by the given filter, or nil if there are none.
+func highestRelease(tags []ReleaseTag, accept func(ReleaseTag) bool) *ReleaseTag {
+	var res *ReleaseTag
+	for i, tag := range tags {
+		if !accept(tag) {
+			continue
+		}
+		if res == nil || tag.GT(res.Version) {
+			res = &tags[i]
+		}
+	}
+	return res
+}
+
+// TagPolicy describes the requirements for the release tags that changelogs
+// are based on.  Releases are supposed to be tagged with `git tag -s`, so
+// tags must always be annotated & signed.
+type TagPolicy struct {
+	// AllowedKeys are the fingerprints (or long key IDs) of the keys that
+	// may sign release tags.  If empty, any key that verifies is allowed.
+	AllowedKeys []string
+}
+
+// normalizeKey makes a fingerprint or key ID comparable, ignoring case,
+// spaces, and any 0x prefix.
+func normalizeKey(key string) string {
+	key = strings.ToUpper(strings.ReplaceAll(key, " ", ""))
+	return strings.TrimPrefix(key, "0X")
+}
+
+// allows checks if one of the given fingerprints belongs to an allowed key.
+// Allowed key IDs match the ends of fingerprints, but short (8-digit) key IDs
+// are too easy to collide with, so they never match.
+func (p TagPolicy) allows(fingerprints []string) bool {
+	if len(p.AllowedKeys) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedKeys {
+		allowed = normalizeKey(allowed)
+		for _, fingerprint := range fingerprints {
+			if len(allowed) >= 16 && strings.HasSuffix(normalizeKey(fingerprint), allowed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TagProblemError indicates that a release tag doesn't meet a TagPolicy.
+type TagProblemError struct {
+	Tag     ReleaseTag
+	Problem string
+}
+
+func (e TagProblemError) Error() string {
+	return fmt.Sprintf("release tag %s %s", e.Tag, e.Problem)
+}
+
+// CheckTag verifies that the given release tag meets the policy, returning a
+// TagProblemError explaining why if it does not.
+func CheckTag(gitImpl git.Git, tag ReleaseTag, policy TagPolicy) error {
+	sig, err := gitImpl.VerifyTag(git.Tag(tag.Committish()))
+	if err != nil {
+		return fmt.Errorf("unable to check release tag %s: %w", tag, err)
+	}
+	switch {
+	case !sig.Annotated:
+		return TagProblemError{Tag: tag, Problem: "is a lightweight tag (release tags should be made with `git tag -s`)"}
+	case !sig.Signed:
+		return TagProblemError{Tag: tag, Problem: "is not signed (release tags should be made with `git tag -s`)"}
+	case !sig.Verified:
+		return TagProblemError{Tag: tag, Problem: "has a signature that could not be verified (is the signing key in your keyring?)"}
+	case !policy.allows(sig.Fingerprints):
+		return TagProblemError{Tag: tag, Problem: fmt.Sprintf("is signed by %s (%s), which is not an allowed key", sig.Signer, strings.Join(sig.Fingerprints, ", "))}
+	default:
+		return nil
+	}
+}
+
+// CreateTag tags the head of the given release branch (the local one, even
+// if UseUpstream is set) as the given release, with the notes in message,
+// signing the tag if requested.  It refuses to tag releases that don't belong
+// on the branch, releases that have already been tagged, and branches that
+// are behind their upstream, or ahead of it if UseUpstream is set (since
+// either way, the notes wouldn't match what was actually released).
+func CreateTag(repo git.Repository, branch ReleaseBranch, tag ReleaseTag, message string, sign bool) error {
+	notesFromUpstream := branch.UseUpstream
+	branch.UseUpstream = false
+	branchName := branch.String()
+	if err := branch.VerifyTagBelongs(tag); err != nil {
+		return err
+	}
+
+	exists, err := repo.HasTag(git.Tag(tag.Committish()))
+	if err != nil {
+		return fmt.Errorf("unable to check for existing release tag %s: %w", tag, err)
+	}
+	if exists {
+		return TagProblemError{Tag: tag, Problem: "already exists"}
+	}
+
+	head := git.SomeCommittish(branchName)
+	upstream := git.SomeCommittish(branchName + "@{u}")
+	if repo.HasUpstream(upstream.Committish()) == nil {
+		upToDate, err := repo.IsAncestor(upstream, head)
+		if err != nil {
+			return fmt.Errorf("unable to compare branch %q to its upstream: %w", branchName, err)
+		}
+		if !upToDate {
+			return fmt.Errorf("branch %q is behind its upstream, pull before tagging", branchName)
+		}
+		if notesFromUpstream {
+			pushed, err := repo.IsAncestor(head, upstream)
+			if err != nil {
+				return fmt.Errorf("unable to compare branch %q to its upstream: %w", branchName, err)
+			}
+			if !pushed {
+				return fmt.Errorf("branch %q is ahead of its upstream (which the notes are from), push before tagging", branchName)
+			}
+		}
+	}
+
+	return repo.CreateTag(git.Tag(tag.Committish()), head, message, sign)
+}
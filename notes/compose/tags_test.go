@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose_test
+
+import (
+	"github.com/blang/semver/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/compose"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git/gittest"
+)
+
+var _ = Describe("Release tag checks", func() {
+	const fingerprint = "3AA5C34371567BD2B5E0A0F6C0F5E6A1D9E8F7A6"
+	tag := ReleaseTag{Version: semver.MustParse("0.6.3")}
+	signedBy := func(sig git.TagSignature) git.Git {
+		return gitFuncs{
+			verifyTag: func(tag git.Tag) (git.TagSignature, error) {
+				Expect(tag).To(Equal(git.Tag("v0.6.3")))
+				return sig, nil
+			},
+		}
+	}
+	verified := git.TagSignature{
+		Annotated:    true,
+		Signed:       true,
+		Verified:     true,
+		Signer:       "Some Maintainer <maint@example.com>",
+		Fingerprints: []string{fingerprint},
+	}
+
+	It("should reject lightweight tags", func() {
+		Expect(CheckTag(signedBy(git.TagSignature{}), tag, TagPolicy{})).To(MatchError(ContainSubstring("lightweight")))
+	})
+
+	It("should reject unsigned tags", func() {
+		Expect(CheckTag(signedBy(git.TagSignature{Annotated: true}), tag, TagPolicy{})).To(MatchError(ContainSubstring("not signed")))
+	})
+
+	It("should reject signatures that couldn't be verified", func() {
+		Expect(CheckTag(signedBy(git.TagSignature{Annotated: true, Signed: true}), tag, TagPolicy{})).To(MatchError(ContainSubstring("could not be verified")))
+	})
+
+	It("should accept any verified signature without an allow-list", func() {
+		Expect(CheckTag(signedBy(verified), tag, TagPolicy{})).To(Succeed())
+	})
+
+	It("should accept signatures from allowed keys, by fingerprint or long key ID", func() {
+		Expect(CheckTag(signedBy(verified), tag, TagPolicy{AllowedKeys: []string{"ABCD", fingerprint}})).To(Succeed())
+		Expect(CheckTag(signedBy(verified), tag, TagPolicy{AllowedKeys: []string{"3aa5 c343 7156 7bd2 b5e0  a0f6 c0f5 e6a1 d9e8 f7a6"}})).To(Succeed())
+		Expect(CheckTag(signedBy(verified), tag, TagPolicy{AllowedKeys: []string{"0xC0F5E6A1D9E8F7A6"}})).To(Succeed())
+	})
+
+	It("should reject signatures from other keys", func() {
+		err := CheckTag(signedBy(verified), tag, TagPolicy{AllowedKeys: []string{"0123456789ABCDEF"}})
+		Expect(err).To(MatchError(TagProblemError{
+			Tag:     tag,
+			Problem: "is signed by Some Maintainer <maint@example.com> (" + fingerprint + "), which is not an allowed key",
+		}))
+	})
+})
+
+var _ = Describe("Creating release tags", func() {
+	var (
+		repo *gittest.Repo
+		rel6 *gittest.Branch
+	)
+	BeforeEach(func() {
+		repo = gittest.NewRepo()
+		main := repo.Branch("main")
+		main.Commit("Initial commit")
+		main.MergePR(1155, ":sparkles: Add Foo field")
+		main.Tag("v0.6.0")
+
+		rel6 = main.Fork("release-0.6")
+		rel6.MergePR(1161, ":bug: Fix leaking goroutine")
+		rel6.Push()
+	})
+	release6 := ReleaseBranch{Version: semver.Version{Minor: 6}, UseUpstream: true}
+	v0_6_1 := ReleaseTag{Version: semver.MustParse("0.6.1")}
+
+	It("should tag the head of the branch with the notes", func() {
+		Expect(CreateTag(repo, release6, v0_6_1, "# v0.6.1\n\n- Fix leaking goroutine (#1161)", false)).To(Succeed())
+
+		Expect(repo.HasTag("v0.6.1")).To(BeTrue())
+		Expect(repo.MergedTags(rel6)).To(ContainElement(git.Tag("v0.6.1")))
+		Expect(repo.IsAncestor(rel6, git.Tag("v0.6.1"))).To(BeTrue())
+		Expect(repo.VerifyTag("v0.6.1")).To(Equal(git.TagSignature{Annotated: true}))
+	})
+
+	It("should refuse to tag a release that's already tagged", func() {
+		rel6.Tag("v0.6.1")
+		Expect(CreateTag(repo, release6, v0_6_1, "v0.6.1", false)).To(MatchError(TagProblemError{Tag: v0_6_1, Problem: "already exists"}))
+	})
+
+	It("should refuse to tag a release from a different branch", func() {
+		err := CreateTag(repo, release6, ReleaseTag{Version: semver.MustParse("0.7.0")}, "v0.7.0", false)
+		Expect(err).To(MatchError(ContainSubstring("does not match")))
+		Expect(repo.HasTag("v0.7.0")).To(BeFalse())
+	})
+
+	It("should refuse to tag a branch that's behind its upstream", func() {
+		rel6.Reset(repo.Commit(":bug: Fix leaking goroutine"))
+		Expect(CreateTag(repo, release6, v0_6_1, "v0.6.1", false)).To(MatchError(ContainSubstring("behind its upstream")))
+		Expect(repo.HasTag("v0.6.1")).To(BeFalse())
+	})
+
+	It("should refuse to tag a branch that's ahead of the upstream the notes are from", func() {
+		rel6.MergePR(1170, ":bug: Fix another thing")
+		Expect(CreateTag(repo, release6, v0_6_1, "v0.6.1", false)).To(MatchError(ContainSubstring("ahead of its upstream")))
+		Expect(repo.HasTag("v0.6.1")).To(BeFalse())
+	})
+
+	It("should tag branches that are ahead of their upstream when not using it, or have none", func() {
+		rel6.MergePR(1170, ":bug: Fix another thing")
+		Expect(CreateTag(repo, ReleaseBranch{Version: semver.Version{Minor: 6}}, v0_6_1, "v0.6.1", false)).To(Succeed())
+		Expect(repo.IsAncestor(rel6, git.Tag("v0.6.1"))).To(BeTrue())
+
+		rel7 := rel6.Fork("release-0.7")
+		rel7.MergePR(1180, ":sparkles: Add Bar field")
+		Expect(CreateTag(repo, ReleaseBranch{Version: semver.Version{Minor: 7}}, ReleaseTag{Version: semver.MustParse("0.7.0")}, "v0.7.0", false)).To(Succeed())
+		Expect(repo.IsAncestor(rel7, git.Tag("v0.7.0"))).To(BeTrue())
+	})
+})
@@ -17,12 +17,15 @@ limitations under the License.
 package compose_test
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/blang/semver/v4"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
+	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
 	. "sigs.k8s.io/kubebuilder-release-tools/notes/compose"
 	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
 )
@@ -31,21 +34,19 @@ var _ = Describe("Versions", func() {
 	Describe("finding the current one", func() {
 		It("should return the latest release on this branch if it matches the branch version", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag("v0.6.3"), nil
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return []git.Tag{"v0.6.3"}, nil
 				},
 			}
 
 			currBranch := ReleaseBranch{Version: semver.Version{Minor: 6}}
-			Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag(
-				semver.Version{Minor: 6, Patch: 3},
-			)))
+			Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 6, Patch: 3}}))
 		})
 
 		It("should return the first commit on this branch if no release exists", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag(""), fmt.Errorf("no tag found!")
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return nil, fmt.Errorf("no tag found!")
 				},
 				firstCommit: func(branchName string) (git.Commit, error) {
 					return git.Commit("abcdef"), nil
@@ -62,11 +63,11 @@ var _ = Describe("Versions", func() {
 		Context("when figuring out if upstreams should be used", func() {
 			It("should clear the upstream on the current branch if no upstream exists", func() {
 				gitImpl := gitFuncs{
-					closestTag: func(initial git.Committish) (git.Tag, error) {
-						if initial.Committish() != "release-0.6" {
-							return git.Tag(""), fmt.Errorf("supplied branch that was probably an upstream: %v", initial)
+					mergedTags: func(into git.Committish) ([]git.Tag, error) {
+						if into.Committish() != "release-0.6" {
+							return nil, fmt.Errorf("supplied branch that was probably an upstream: %v", into)
 						}
-						return git.Tag("v0.6.3"), nil
+						return []git.Tag{"v0.6.3"}, nil
 					},
 					hasUpstream: func(branchName string) error {
 						if branchName == "release-0.6@{u}" {
@@ -84,11 +85,11 @@ var _ = Describe("Versions", func() {
 
 			It("should keep the upstream around if one does exist", func() {
 				gitImpl := gitFuncs{
-					closestTag: func(initial git.Committish) (git.Tag, error) {
-						if initial.Committish() != "release-0.6@{u}" {
-							return git.Tag(""), fmt.Errorf("supplied branch that was not an upstream: %v", initial)
+					mergedTags: func(into git.Committish) ([]git.Tag, error) {
+						if into.Committish() != "release-0.6@{u}" {
+							return nil, fmt.Errorf("supplied branch that was not an upstream: %v", into)
 						}
-						return git.Tag("v0.6.3"), nil
+						return []git.Tag{"v0.6.3"}, nil
 					},
 					hasUpstream: func(branchName string) error {
 						if branchName == "release-0.6@{u}" {
@@ -106,12 +107,12 @@ var _ = Describe("Versions", func() {
 
 			It("should keep using upstreams when looking back a branch, even if the current one lacked one, if we originally asked to", func() {
 				gitImpl := gitFuncs{
-					closestTag: func(initial git.Committish) (git.Tag, error) {
-						switch initial.Committish() {
+					mergedTags: func(into git.Committish) ([]git.Tag, error) {
+						switch into.Committish() {
 						case "release-0.6":
-							return git.Tag("v0.5.0"), nil
+							return []git.Tag{"v0.5.0"}, nil
 						case "release-0.5@{u}": // still using the upstream for older branches
-							return git.Tag("v0.5.7"), nil
+							return []git.Tag{"v0.5.7"}, nil
 						default:
 							panic("unexpected branch requested")
 						}
@@ -125,9 +126,7 @@ var _ = Describe("Versions", func() {
 				}
 
 				currBranch := ReleaseBranch{Version: semver.Version{Minor: 6}, UseUpstream: true}
-				Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag(
-					semver.Version{Minor: 5, Patch: 7},
-				)))
+				Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 5, Patch: 7}}))
 				Expect(currBranch.UseUpstream).To(BeFalse())
 			})
 		})
@@ -135,72 +134,66 @@ var _ = Describe("Versions", func() {
 		Context("when the latest release belongs to the previous release", func() {
 			It("should return the latest release on that release-0.(Y-1) branch", func() {
 				gitImpl := gitFuncs{
-					closestTag: func(initial git.Committish) (git.Tag, error) {
-						switch initial.Committish() {
+					mergedTags: func(into git.Committish) ([]git.Tag, error) {
+						switch into.Committish() {
 						case "release-0.7":
-							return git.Tag("v0.6.0"), nil
+							return []git.Tag{"v0.6.0"}, nil
 						case "release-0.6":
-							return git.Tag("v0.6.3"), nil
+							return []git.Tag{"v0.6.3"}, nil
 						default:
-							panic(fmt.Sprintf("got unexpected commit %v for ClosestTag", initial))
+							panic(fmt.Sprintf("got unexpected commit %v for MergedTags", into))
 						}
 					},
 				}
 
 				currBranch := ReleaseBranch{Version: semver.Version{Minor: 7}}
-				Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag(
-					semver.Version{Minor: 6, Patch: 3},
-				)))
+				Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 6, Patch: 3}}))
 			})
 			It("should return the latest release on that release-(X-1) branch", func() {
 				gitImpl := gitFuncs{
-					closestTag: func(initial git.Committish) (git.Tag, error) {
-						switch initial.Committish() {
+					mergedTags: func(into git.Committish) ([]git.Tag, error) {
+						switch into.Committish() {
 						case "release-2":
-							return git.Tag("v1.0.0"), nil
+							return []git.Tag{"v1.0.0"}, nil
 						case "release-1":
-							return git.Tag("v1.9.6"), nil
+							return []git.Tag{"v1.9.6"}, nil
 						default:
-							panic(fmt.Sprintf("got unexpected commit %v for ClosestTag", initial))
+							panic(fmt.Sprintf("got unexpected commit %v for MergedTags", into))
 						}
 					},
 				}
 
 				currBranch := ReleaseBranch{Version: semver.Version{Major: 2}}
-				Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag(
-					semver.Version{Major: 1, Minor: 9, Patch: 6},
-				)))
+				Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 9, Patch: 6}}))
 			})
 			It("should return the latest release on that release-0.Y branch if this is a release-1 branch", func() {
 				gitImpl := gitFuncs{
-					closestTag: func(initial git.Committish) (git.Tag, error) {
-						switch initial.Committish() {
+					mergedTags: func(into git.Committish) ([]git.Tag, error) {
+						switch into.Committish() {
 						case "release-1":
-							return git.Tag("v0.6.0"), nil
+							return []git.Tag{"v0.6.0"}, nil
 						case "release-0.6":
-							return git.Tag("v0.6.3"), nil
+							return []git.Tag{"v0.6.3"}, nil
 						default:
-							panic(fmt.Sprintf("got unexpected commit %v for ClosestTag", initial))
+							panic(fmt.Sprintf("got unexpected commit %v for MergedTags", into))
 						}
 					},
 				}
 
 				currBranch := ReleaseBranch{Version: semver.Version{Major: 1}}
-				Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag(
-					semver.Version{Minor: 6, Patch: 3},
-				)))
+				Expect(CurrentVersion(gitImpl, &currBranch)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 6, Patch: 3}}))
 			})
 
 			It("should fail if the previous branch has a release that doesn't belong to it", func() {
 				gitImpl := gitFuncs{
-					closestTag: func(initial git.Committish) (git.Tag, error) {
-						switch initial.Committish() {
+					mergedTags: func(into git.Committish) ([]git.Tag, error) {
+						switch into.Committish() {
 						case "release-1":
-							return git.Tag("v0.6.0"), nil
+							return []git.Tag{"v0.6.0"}, nil
 						case "release-0.6":
-							return git.Tag("v0.5.0"), nil
+							return []git.Tag{"v0.5.0"}, nil
 						default:
-							panic(fmt.Sprintf("got unexpected commit %v for ClosestTag", initial))
+							panic(fmt.Sprintf("got unexpected commit %v for MergedTags", into))
 						}
 					},
 				}
@@ -213,8 +206,8 @@ var _ = Describe("Versions", func() {
 
 		It("should fail if the latest release doesn't belong to the current or previous release branch", func() {
 			gitImpl := gitFuncs{
-				closestTag: func(initial git.Committish) (git.Tag, error) {
-					return git.Tag("v0.6.0"), nil
+				mergedTags: func(into git.Committish) ([]git.Tag, error) {
+					return []git.Tag{"v0.6.0"}, nil
 				},
 			}
 
@@ -231,16 +224,14 @@ var _ = Describe("Versions", func() {
 
 			Context("from final releases", func() {
 				Context("with X>=1 releases", func() {
-					current := ReleaseTag(semver.Version{Major: 1, Minor: 6, Patch: 3})
+					current := ReleaseTag{Version: semver.Version{Major: 1, Minor: 6, Patch: 3}}
 
 					It("should bump X on breaking changes", func() {
 						log := ChangeLog{
 							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Major: 2},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 2}}))
 					})
 
 					It("should bump Y on new features", func() {
@@ -248,32 +239,26 @@ var _ = Describe("Versions", func() {
 							Features: []LogEntry{{Title: "some feature", PRNumber: "44"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Major: 1, Minor: 7},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 7}}))
 					})
 
 					It("should bump Z on anything else", func() {
 						log := ChangeLog{
 							Bugs: []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Major: 1, Minor: 6, Patch: 4},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 6, Patch: 4}}))
 					})
 				})
 
 				Context("with 0.Y releases", func() {
-					current := ReleaseTag(semver.Version{Minor: 6, Patch: 3})
+					current := ReleaseTag{Version: semver.Version{Minor: 6, Patch: 3}}
 
 					It("should bump Y on breaking changes with Pre10 set to true", func() {
 						log := ChangeLog{
 							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 							Features: []LogEntry{{Title: "some feature", PRNumber: "44"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Minor: 7},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 7}}))
 					})
 
 					It("should bump Y on new features", func() {
@@ -281,18 +266,14 @@ var _ = Describe("Versions", func() {
 							Features: []LogEntry{{Title: "some feature", PRNumber: "44"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Minor: 7},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 7}}))
 					})
 
 					It("should bump Z on anything else", func() {
 						log := ChangeLog{
 							Docs: []LogEntry{{Title: "some doc change", PRNumber: "66"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Minor: 6, Patch: 4},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 6, Patch: 4}}))
 					})
 
 					It("should bump 0.Y to 1.0.0 if Pre10 is false", func() {
@@ -301,24 +282,68 @@ var _ = Describe("Versions", func() {
 							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, v1Info)).To(Equal(ReleaseTag(
-							semver.Version{Major: 1},
-						)))
+						Expect(log.ExpectedNextVersion(current, v1Info)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1}}))
 					})
 				})
+
+				Context("when starting a new release line", func() {
+					newLineInfo := ReleaseInfo{Kind: ReleaseFinal, Pre10: true, NewLine: true}
+
+					It("should bump at least Y, even with only bug fixes", func() {
+						log := ChangeLog{
+							Bugs: []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
+						}
+						Expect(log.ExpectedNextVersion(ReleaseTag{Version: semver.Version{Minor: 6, Patch: 3}}, newLineInfo)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 7}}))
+					})
+
+					It("should still bump X on breaking changes", func() {
+						log := ChangeLog{
+							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
+						}
+						Expect(log.ExpectedNextVersion(ReleaseTag{Version: semver.Version{Major: 1, Minor: 6, Patch: 3}}, newLineInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 2}}))
+					})
+				})
+			})
+			Context("with custom PR types", func() {
+				current := ReleaseTag{Version: semver.Version{Major: 1, Minor: 6, Patch: 3}}
+				AfterEach(func() {
+					common.SetTaxonomy(nil)
+				})
+
+				It("should bump according to the impact of the custom type", func() {
+					taxonomy, err := common.NewTaxonomy(common.TypeInfo{Name: "deprecation", Impact: common.ImpactMinor})
+					Expect(err).NotTo(HaveOccurred())
+					common.SetTaxonomy(taxonomy)
+					deprecation, _ := taxonomy.ByName("deprecation")
+
+					log := ChangeLog{
+						Bugs:  []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
+						Other: map[common.PRType][]LogEntry{deprecation: {{Title: "deprecate a thing", PRNumber: "77"}}},
+					}
+					Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 7}}))
+				})
+
+				It("should bump according to overridden impacts of built-in types", func() {
+					taxonomy, err := common.NewTaxonomy(common.TypeInfo{Name: "feature", Impact: common.ImpactPatch})
+					Expect(err).NotTo(HaveOccurred())
+					common.SetTaxonomy(taxonomy)
+
+					log := ChangeLog{
+						Features: []LogEntry{{Title: "some feature", PRNumber: "44"}},
+					}
+					Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 6, Patch: 4}}))
+				})
 			})
 			Context("from pre-releases", func() {
-				current := ReleaseTag(semver.Version{Major: 2, Pre: []semver.PRVersion{
+				current := ReleaseTag{Version: semver.Version{Major: 2, Pre: []semver.PRVersion{
 					{VersionStr: "rc"}, {VersionNum: 4, IsNum: true},
-				}})
+				}}}
 				It("should just clear the pre-release tag, keeping the version", func() {
 					log := ChangeLog{
 						Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 						Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 					}
-					Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-						semver.Version{Major: 2},
-					)))
+					Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 2}}))
 				})
 			})
 		})
@@ -332,16 +357,14 @@ var _ = Describe("Versions", func() {
 
 			Context("from final releases", func() {
 				Context("with X>=1 releases", func() {
-					current := ReleaseTag(semver.Version{Major: 1, Minor: 6, Patch: 3})
+					current := ReleaseTag{Version: semver.Version{Major: 1, Minor: 6, Patch: 3}}
 
 					It("should bump X on breaking changes", func() {
 						log := ChangeLog{
 							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Major: 2, Pre: betaPre(0)},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 2, Pre: betaPre(0)}}))
 					})
 
 					It("should bump Y on new features", func() {
@@ -349,32 +372,26 @@ var _ = Describe("Versions", func() {
 							Features: []LogEntry{{Title: "some feature", PRNumber: "44"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Major: 1, Minor: 7, Pre: betaPre(0)},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 7, Pre: betaPre(0)}}))
 					})
 
 					It("should bump Z on anything else", func() {
 						log := ChangeLog{
 							Bugs: []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Major: 1, Minor: 6, Patch: 4, Pre: betaPre(0)},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Minor: 6, Patch: 4, Pre: betaPre(0)}}))
 					})
 				})
 
 				Context("with 0.Y releases", func() {
-					current := ReleaseTag(semver.Version{Minor: 6, Patch: 3})
+					current := ReleaseTag{Version: semver.Version{Minor: 6, Patch: 3}}
 
 					It("should bump Y on breaking changes with Pre10 set to true", func() {
 						log := ChangeLog{
 							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 							Features: []LogEntry{{Title: "some feature", PRNumber: "44"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Minor: 7, Pre: betaPre(0)},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 7, Pre: betaPre(0)}}))
 					})
 
 					It("should bump Y on new features", func() {
@@ -382,18 +399,14 @@ var _ = Describe("Versions", func() {
 							Features: []LogEntry{{Title: "some feature", PRNumber: "44"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Minor: 7, Pre: betaPre(0)},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 7, Pre: betaPre(0)}}))
 					})
 
 					It("should bump Z on anything else", func() {
 						log := ChangeLog{
 							Docs: []LogEntry{{Title: "some doc change", PRNumber: "66"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Minor: 6, Patch: 4, Pre: betaPre(0)},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Minor: 6, Patch: 4, Pre: betaPre(0)}}))
 					})
 
 					It("should bump 0.Y to 1.0.0 if Pre10 is false", func() {
@@ -402,25 +415,21 @@ var _ = Describe("Versions", func() {
 							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, v1Info)).To(Equal(ReleaseTag(
-							semver.Version{Major: 1, Pre: betaPre(0)},
-						)))
+						Expect(log.ExpectedNextVersion(current, v1Info)).To(Equal(ReleaseTag{Version: semver.Version{Major: 1, Pre: betaPre(0)}}))
 					})
 				})
 			})
 			Context("from pre-releases", func() {
-				current := ReleaseTag(semver.Version{Major: 2, Pre: []semver.PRVersion{
+				current := ReleaseTag{Version: semver.Version{Major: 2, Pre: []semver.PRVersion{
 					{VersionStr: "beta"}, {VersionNum: 0, IsNum: true},
-				}})
+				}}}
 				Context("with the same kind of pre-release", func() {
 					It("should just increment the pre-release number", func() {
 						log := ChangeLog{
 							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag(
-							semver.Version{Major: 2, Pre: betaPre(1)},
-						)))
+						Expect(log.ExpectedNextVersion(current, relInfo)).To(Equal(ReleaseTag{Version: semver.Version{Major: 2, Pre: betaPre(1)}}))
 					})
 				})
 				Context("with a different kind of pre-release", func() {
@@ -430,11 +439,11 @@ var _ = Describe("Versions", func() {
 							Breaking: []LogEntry{{Title: "something major", PRNumber: "33"}},
 							Bugs:     []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
 						}
-						Expect(log.ExpectedNextVersion(current, rcInfo)).To(Equal(ReleaseTag(
-							semver.Version{Major: 2, Pre: []semver.PRVersion{
+						Expect(log.ExpectedNextVersion(current, rcInfo)).To(Equal(ReleaseTag{
+							Version: semver.Version{Major: 2, Pre: []semver.PRVersion{
 								{VersionStr: "rc"}, {VersionNum: 0, IsNum: true},
 							}},
-						)))
+						}))
 					})
 
 					It("should reject trying to return to older pre-release kinds", func() {
@@ -445,10 +454,230 @@ var _ = Describe("Versions", func() {
 						}
 
 						_, err := log.ExpectedNextVersion(current, alphaInfo)
-						Expect(err).To(HaveOccurred())
+						Expect(err).To(MatchError(PreReleaseTransitionError{Current: current, Kind: ReleaseAlpha}))
 					})
 				})
 			})
 		})
+
+		Context("when switching between kinds of release", func() {
+			log := ChangeLog{
+				Bugs: []LogEntry{{Title: "some bugfix", PRNumber: "55"}},
+			}
+
+			DescribeTable("should bump or renumber the version according to the kinds",
+				func(current string, kind ReleaseKind, expected string) {
+					Expect(log.ExpectedNextVersion(ReleaseTag{Version: semver.MustParse(current)}, ReleaseInfo{Kind: kind})).To(Equal(ReleaseTag{Version: semver.MustParse(expected)}))
+				},
+				Entry("final --> final", "1.6.3", ReleaseFinal, "1.6.4"),
+				Entry("final --> alpha", "1.6.3", ReleaseAlpha, "1.6.4-alpha.0"),
+				Entry("final --> beta", "1.6.3", ReleaseBeta, "1.6.4-beta.0"),
+				Entry("final --> rc", "1.6.3", ReleaseCandidate, "1.6.4-rc.0"),
+				Entry("alpha --> final", "1.7.0-alpha.2", ReleaseFinal, "1.7.0"),
+				Entry("alpha --> alpha", "1.7.0-alpha.2", ReleaseAlpha, "1.7.0-alpha.3"),
+				Entry("alpha --> beta", "1.7.0-alpha.2", ReleaseBeta, "1.7.0-beta.0"),
+				Entry("alpha --> rc", "1.7.0-alpha.2", ReleaseCandidate, "1.7.0-rc.0"),
+				Entry("beta --> final", "1.7.0-beta.2", ReleaseFinal, "1.7.0"),
+				Entry("beta --> beta", "1.7.0-beta.2", ReleaseBeta, "1.7.0-beta.3"),
+				Entry("beta --> rc", "1.7.0-beta.2", ReleaseCandidate, "1.7.0-rc.0"),
+				Entry("rc --> final", "1.7.0-rc.2", ReleaseFinal, "1.7.0"),
+				Entry("rc --> rc", "1.7.0-rc.2", ReleaseCandidate, "1.7.0-rc.3"),
+			)
+
+			DescribeTable("should refuse to go back to earlier kinds of pre-release",
+				func(current string, kind ReleaseKind) {
+					currentTag := ReleaseTag{Version: semver.MustParse(current)}
+					_, err := log.ExpectedNextVersion(currentTag, ReleaseInfo{Kind: kind})
+					Expect(err).To(MatchError(PreReleaseTransitionError{Current: currentTag, Kind: kind}))
+					Expect(err).To(MatchError(ContainSubstring("never back")))
+				},
+				Entry("beta --> alpha", "1.7.0-beta.2", ReleaseAlpha),
+				Entry("rc --> alpha", "1.7.0-rc.2", ReleaseAlpha),
+				Entry("rc --> beta", "1.7.0-rc.2", ReleaseBeta),
+			)
+
+			DescribeTable("should start at v0.1.0 if nothing's been released",
+				func(kind ReleaseKind, expected string) {
+					Expect(log.ExpectedNextVersion(FirstCommit{Commit: "abcdef"}, ReleaseInfo{Kind: kind})).To(Equal(ReleaseTag{Version: semver.MustParse(expected)}))
+				},
+				Entry("final", ReleaseFinal, "0.1.0"),
+				Entry("alpha", ReleaseAlpha, "0.1.0-alpha.0"),
+				Entry("beta", ReleaseBeta, "0.1.0-beta.0"),
+				Entry("rc", ReleaseCandidate, "0.1.0-rc.0"),
+			)
+
+			Context("with an initial version", func() {
+				initial := ReleaseTag{Version: semver.MustParse("1.0.0")}
+				release1 := ReleaseBranch{Version: semver.Version{Major: 1}}
+
+				DescribeTable("should start there if nothing's been released",
+					func(kind ReleaseKind, expected string) {
+						Expect(log.ExpectedNextVersion(FirstCommit{Commit: "abcdef", Branch: release1}, ReleaseInfo{Kind: kind, Initial: &initial})).To(Equal(ReleaseTag{Version: semver.MustParse(expected)}))
+					},
+					Entry("final", ReleaseFinal, "1.0.0"),
+					Entry("alpha", ReleaseAlpha, "1.0.0-alpha.0"),
+					Entry("rc", ReleaseCandidate, "1.0.0-rc.0"),
+				)
+
+				It("should ignore it once something's been released", func() {
+					Expect(log.ExpectedNextVersion(ReleaseTag{Version: semver.MustParse("1.0.0-alpha.0")}, ReleaseInfo{Kind: ReleaseAlpha, Initial: &initial})).To(Equal(ReleaseTag{Version: semver.MustParse("1.0.0-alpha.1")}))
+				})
+
+				It("should reject initial versions that don't belong on the branch", func() {
+					release0_7 := ReleaseBranch{Version: semver.Version{Minor: 7}}
+					_, err := log.ExpectedNextVersion(FirstCommit{Commit: "abcdef", Branch: release0_7}, ReleaseInfo{Initial: &initial})
+					Expect(err).To(MatchError(ContainSubstring("does not match the branch's version")))
+				})
+
+				It("should accept any initial version on development branches", func() {
+					Expect(log.ExpectedNextVersion(FirstCommit{Commit: "abcdef", Branch: DevelopmentBranch("main")}, ReleaseInfo{Initial: &initial, NewLine: true})).To(Equal(initial))
+				})
+
+				It("should reject initial versions with pre-release info", func() {
+					alpha := ReleaseTag{Version: semver.MustParse("1.0.0-alpha.0")}
+					_, err := log.ExpectedNextVersion(FirstCommit{Commit: "abcdef", Branch: release1}, ReleaseInfo{Kind: ReleaseAlpha, Initial: &alpha})
+					Expect(err).To(MatchError(ContainSubstring("must be a final version")))
+				})
+			})
+
+			It("should reject unknown pre-release identifiers on the current release", func() {
+				_, err := log.ExpectedNextVersion(ReleaseTag{Version: semver.MustParse("1.7.0-candidate.0")}, ReleaseInfo{Kind: ReleaseCandidate})
+				Expect(err).To(MatchError(ContainSubstring("invalid pre-release identifier")))
+			})
+		})
+	})
+
+	Describe("explaining the next one", func() {
+		log := ChangeLog{
+			Breaking: []LogEntry{{Title: "Remove the old client", PRNumber: "33"}},
+			Features: []LogEntry{{Title: "Add Foo field", PRNumber: "44"}},
+			Bugs:     []LogEntry{{Title: "Fix leaking goroutine", PRNumber: "55"}},
+		}
+		bugsOnly := ChangeLog{Bugs: log.Bugs}
+		featuresOnly := ChangeLog{Features: log.Features, Bugs: log.Bugs}
+		v1_0_0 := ReleaseTag{Version: semver.MustParse("1.0.0")}
+
+		DescribeTable("should record the rule used & the changes that triggered it",
+			func(changes ChangeLog, current git.Committish, info ReleaseInfo, expected VersionDecision) {
+				Expect(changes.ExplainNextVersion(current, info)).To(Equal(expected))
+			},
+			Entry("breaking changes", log, ReleaseTag{Version: semver.MustParse("1.6.3")}, ReleaseInfo{Pre10: true}, VersionDecision{
+				Current:  "v1.6.3",
+				Next:     ReleaseTag{Version: semver.MustParse("2.0.0")},
+				Rule:     RuleBreakingBump,
+				Triggers: []VersionTrigger{{Type: "breaking", PRNumber: "33", Title: "Remove the old client"}},
+			}),
+			Entry("breaking changes clamped by Pre10", log, ReleaseTag{Version: semver.MustParse("0.6.3")}, ReleaseInfo{Pre10: true}, VersionDecision{
+				Current:  "v0.6.3",
+				Next:     ReleaseTag{Version: semver.MustParse("0.7.0")},
+				Rule:     RulePre10Clamp,
+				Triggers: []VersionTrigger{{Type: "breaking", PRNumber: "33", Title: "Remove the old client"}},
+			}),
+			Entry("features", featuresOnly, ReleaseTag{Version: semver.MustParse("0.6.3")}, ReleaseInfo{Kind: ReleaseBeta}, VersionDecision{
+				Current:  "v0.6.3",
+				Next:     ReleaseTag{Version: semver.MustParse("0.7.0-beta.0")},
+				Rule:     RuleFeatureBump,
+				Triggers: []VersionTrigger{{Type: "feature", PRNumber: "44", Title: "Add Foo field"}},
+			}),
+			Entry("anything else", bugsOnly, ReleaseTag{Version: semver.MustParse("0.6.3")}, ReleaseInfo{}, VersionDecision{
+				Current:  "v0.6.3",
+				Next:     ReleaseTag{Version: semver.MustParse("0.6.4")},
+				Rule:     RulePatchBump,
+				Triggers: []VersionTrigger{{Type: "bugfix", PRNumber: "55", Title: "Fix leaking goroutine"}},
+			}),
+			Entry("new release lines", bugsOnly, ReleaseTag{Version: semver.MustParse("0.6.3")}, ReleaseInfo{NewLine: true}, VersionDecision{
+				Current: "v0.6.3",
+				Next:    ReleaseTag{Version: semver.MustParse("0.7.0")},
+				Rule:    RuleNewLine,
+			}),
+			Entry("pre-release --> final", log, ReleaseTag{Version: semver.MustParse("0.7.0-rc.1")}, ReleaseInfo{}, VersionDecision{
+				Current: "v0.7.0-rc.1",
+				Next:    ReleaseTag{Version: semver.MustParse("0.7.0")},
+				Rule:    RulePreToFinal,
+			}),
+			Entry("the same kind of pre-release", log, ReleaseTag{Version: semver.MustParse("0.7.0-rc.1")}, ReleaseInfo{Kind: ReleaseCandidate}, VersionDecision{
+				Current: "v0.7.0-rc.1",
+				Next:    ReleaseTag{Version: semver.MustParse("0.7.0-rc.2")},
+				Rule:    RulePreIncrement,
+			}),
+			Entry("a later kind of pre-release", log, ReleaseTag{Version: semver.MustParse("0.7.0-alpha.1")}, ReleaseInfo{Kind: ReleaseCandidate}, VersionDecision{
+				Current: "v0.7.0-alpha.1",
+				Next:    ReleaseTag{Version: semver.MustParse("0.7.0-rc.0")},
+				Rule:    RulePreAdvance,
+			}),
+			Entry("the first release", log, FirstCommit{Commit: "abcdef"}, ReleaseInfo{}, VersionDecision{
+				Current: "abcdef",
+				Next:    ReleaseTag{Version: semver.MustParse("0.1.0")},
+				Rule:    RuleFirstRelease,
+			}),
+			Entry("the configured initial version", log, FirstCommit{Commit: "abcdef", Branch: ReleaseBranch{Version: semver.Version{Major: 1}}}, ReleaseInfo{Kind: ReleaseBeta, Initial: &v1_0_0}, VersionDecision{
+				Current: "abcdef",
+				Next:    ReleaseTag{Version: semver.MustParse("1.0.0-beta.0")},
+				Rule:    RuleInitialVersion,
+			}),
+		)
+
+		It("should explain the decision for humans", func() {
+			decision, err := featuresOnly.ExplainNextVersion(ReleaseTag{Version: semver.MustParse("0.6.3")}, ReleaseInfo{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decision.String()).To(Equal("v0.7.0 (from v0.6.3): new features bump the minor version, because of:\n  - [feature] Add Foo field (#44)"))
+		})
+
+		It("should marshal the decision to JSON with the versions as tag names", func() {
+			decision, err := featuresOnly.ExplainNextVersion(ReleaseTag{Version: semver.MustParse("0.6.3")}, ReleaseInfo{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Marshal(decision)).To(MatchJSON(`{
+				"current": "v0.6.3",
+				"next": "v0.7.0",
+				"rule": "feature-bump",
+				"triggers": [{"type": "feature", "pr": "44", "title": "Add Foo field"}]
+			}`))
+		})
+	})
+
+	Describe("kinds of release", func() {
+		DescribeTable("should parse the names of kinds of release",
+			func(raw string, expected ReleaseKind) {
+				Expect(ParseReleaseKind(raw)).To(Equal(expected))
+				Expect(expected.String()).To(Equal(raw))
+			},
+			Entry("final", "final", ReleaseFinal),
+			Entry("alpha", "alpha", ReleaseAlpha),
+			Entry("beta", "beta", ReleaseBeta),
+			Entry("rc", "rc", ReleaseCandidate),
+		)
+
+		It("should reject unknown kinds of release", func() {
+			_, err := ParseReleaseKind("candidate")
+			Expect(err).To(HaveOccurred())
+			Expect(ReleaseKind(7).Validate()).NotTo(Succeed())
+		})
+
+		DescribeTable("should validate the pre-release info of release tags",
+			func(raw string, valid bool) {
+				tag := ReleaseTag{Version: semver.MustParse(raw)}
+				if valid {
+					Expect(tag.Validate()).To(Succeed())
+				} else {
+					Expect(tag.Validate()).NotTo(Succeed())
+				}
+			},
+			Entry("final releases", "1.2.3", true),
+			Entry("alphas", "1.2.3-alpha.0", true),
+			Entry("betas", "1.2.3-beta.4", true),
+			Entry("release candidates", "1.2.3-rc.10", true),
+			Entry("unknown identifiers", "1.2.3-candidate.1", false),
+			Entry("the final kind as an identifier", "1.2.3-final.1", false),
+			Entry("missing numbers", "1.2.3-rc", false),
+			Entry("non-numeric numbers", "1.2.3-rc.x", false),
+			Entry("numeric identifiers", "1.2.3-1.rc", false),
+			Entry("extra parts", "1.2.3-rc.1.2", false),
+		)
+
+		It("should know the kind of release tags", func() {
+			Expect(ReleaseTag{Version: semver.MustParse("1.2.3")}.Kind()).To(Equal(ReleaseFinal))
+			Expect(ReleaseTag{Version: semver.MustParse("1.2.3-beta.1")}.Kind()).To(Equal(ReleaseBeta))
+			Expect(ReleaseTag{Version: semver.MustParse("1.2.3-rc.0")}.Kind()).To(Equal(ReleaseCandidate))
+		})
 	})
 })
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CommitRecord describes a single commit from the history.
+type CommitRecord struct {
+	SHA     Commit
+	Parents []Commit
+
+	AuthorName  string
+	AuthorEmail string
+	// Date is the committer date, which (unlike the author date) is when the
+	// commit actually landed on the branch.
+	Date time.Time
+
+	// Subject is the first paragraph of the message, with lines joined by
+	// spaces (like git's %s).
+	Subject string
+	// Body is the rest of the message, without leading blank lines or
+	// trailing whitespace.
+	Body string
+	// Trailers are the `Key: value` lines in the final paragraph of the body
+	// (like `Signed-off-by`), if that paragraph consists only of those.
+	Trailers []Trailer
+}
+
+// Trailer is a `Key: value` line at the end of a commit message.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// IsMerge checks if this is a merge commit (has more than one parent).
+func (c CommitRecord) IsMerge() bool {
+	return len(c.Parents) > 1
+}
+
+// trailerRE matches a single trailer line.
+var trailerRE = regexp.MustCompile(`^([[:alnum:]-]+):\s*(.*)$`)
+
+// setMessage fills out the subject, body, and trailers of this record from
+// the given raw message.
+func (c *CommitRecord) setMessage(message string) {
+	paragraphs := strings.SplitN(strings.TrimLeft(message, "\n"), "\n\n", 2)
+	subjectLines := strings.Split(strings.TrimSpace(paragraphs[0]), "\n")
+	for i, line := range subjectLines {
+		subjectLines[i] = strings.TrimSpace(line)
+	}
+	c.Subject = strings.Join(subjectLines, " ")
+	if len(paragraphs) < 2 {
+		return
+	}
+	c.Body = strings.TrimRight(strings.TrimLeft(paragraphs[1], "\n"), " \t\n")
+	c.Trailers = parseTrailers(c.Body)
+}
+
+// parseTrailers parses the trailers from the last paragraph of the given
+// body, returning nil if it's not made up of trailers.  Lines starting with
+// whitespace continue the previous trailer.
+func parseTrailers(body string) []Trailer {
+	paragraphs := strings.Split(body, "\n\n")
+	var res []Trailer
+	for _, line := range strings.Split(paragraphs[len(paragraphs)-1], "\n") {
+		if len(res) > 0 && strings.TrimLeft(line, " \t") != line {
+			res[len(res)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		parts := trailerRE.FindStringSubmatch(line)
+		if parts == nil {
+			return nil
+		}
+		res = append(res, Trailer{Key: parts[1], Value: strings.TrimSpace(parts[2])})
+	}
+	return res
+}
+
+// logFormat is the `git log` format used to produce commit records, along
+// with the -z flag.  Fields are separated by NULs, and commits are terminated
+// by them, so messages can contain anything but NULs (which git doesn't allow
+// anyway).
+const logFormat = "--format=%H%x00%P%x00%an%x00%ae%x00%cI%x00%B"
+
+// logFields is the number of fields per commit in logFormat.
+const logFields = 6
+
+// parseLog parses the output of `git log -z` with logFormat into commit
+// records.
+func parseLog(raw string) ([]CommitRecord, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	fields := strings.Split(strings.TrimSuffix(raw, "\x00"), "\x00")
+	if len(fields)%logFields != 0 {
+		return nil, fmt.Errorf("unexpected git log output: %d fields is not a multiple of %d", len(fields), logFields)
+	}
+
+	res := make([]CommitRecord, 0, len(fields)/logFields)
+	for ; len(fields) > 0; fields = fields[logFields:] {
+		date, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse commit date of %s: %w", fields[0], err)
+		}
+		record := CommitRecord{
+			SHA:         Commit(fields[0]),
+			AuthorName:  fields[2],
+			AuthorEmail: fields[3],
+			Date:        date,
+		}
+		for _, parent := range strings.Fields(fields[1]) {
+			record.Parents = append(record.Parents, Commit(parent))
+		}
+		record.setMessage(fields[5])
+		res = append(res, record)
+	}
+	return res, nil
+}
+
+// patchID computes the ID of the changes in the given unified diff: a hash of
+// the added & removed lines, along with the names of the files they're in,
+// ignoring whitespace, context lines, and line numbers.  That's a bit looser
+// than `git patch-id` (which hashes context lines too), so that backports
+// whose surroundings differ a little still match, and so that it doesn't
+// depend on how the diff was produced.  Diffs with no added or removed lines
+// have an empty ID.
+func patchID(patch string) string {
+	hash := sha1.New()
+	changed := false
+	// inHunk indicates we're past the file header (the `diff`, `---`, and
+	// `+++` lines, etc), so lines like `--- x` are removed lines, not file
+	// names
+	inHunk := false
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			inHunk = false
+			continue
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+			continue
+		case !strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-"):
+			continue
+		case inHunk:
+			changed = true
+		case !strings.HasPrefix(line, "+++ ") && !strings.HasPrefix(line, "--- "):
+			// other header lines aren't part of the change
+			continue
+		}
+		fmt.Fprintln(hash, strings.Join(strings.Fields(line), ""))
+	}
+	if !changed {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,454 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gittest builds in-memory git repositories for tests.
+//
+// Histories are built up branch by branch, and the resulting Repo implements
+// git.Repository with the same semantics as the real git binary (it's backed
+// by git.GoGit), so tests can model actual topologies -- release branches
+// forking from main, cherry-picks, tags on several branches, upstreams --
+// instead of canning the output of each call:
+//
+//	repo := gittest.NewRepo()
+//	main := repo.Branch("main")
+//	main.Commit("Initial commit")
+//	main.MergePR(1155, ":sparkles: Add Foo field")
+//	main.Tag("v0.6.0")
+//
+//	rel := main.Fork("release-0.6")
+//	rel.MergePR(1161, ":bug: Fix leaking goroutine")
+//	rel.Tag("v0.6.1")
+//	rel.Push()
+//
+// Commits don't change any files unless made through a branch from Changing,
+// so that path-based filtering can be tested:
+//
+//	main.Changing("tools/setup-envtest/main.go").MergePR(1170, ":bug: Fix envtest")
+//
+// Errors building the repository are programming errors in the test, so they
+// cause panics.
+package gittest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+)
+
+// DefaultRemote is the remote that Push sets up upstreams on.
+const DefaultRemote = "origin"
+
+// Repo is an in-memory git repository.
+type Repo struct {
+	*git.GoGit
+
+	repo *gogit.Repository
+	// clock is the time used for the next commit or tag, so that histories
+	// have a well-defined order.
+	clock time.Time
+	// edits counts file changes, so that each one has unique contents.
+	edits int
+	// checkedOut indicates that some branch has been checked out.
+	checkedOut bool
+	// prs records the PR that each commit made by the PR helpers came from,
+	// like GitHub does.
+	prs map[git.Commit]int
+}
+
+// NewRepo creates an empty in-memory repository with DefaultRemote set up
+// to point at a GitHub repository, and a user configured.
+func NewRepo() *Repo {
+	repo, err := gogit.Init(memory.NewStorage(), nil)
+	if err != nil {
+		panic(fmt.Sprintf("unable to create in-memory repository: %v", err))
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: DefaultRemote,
+		URLs: []string{"https://github.com/kubernetes-sigs/controller-runtime.git"},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("unable to create remote: %v", err))
+	}
+	// tags created through git.Repository need someone to create them
+	cfg, err := repo.Config()
+	if err != nil {
+		panic(fmt.Sprintf("unable to read config: %v", err))
+	}
+	cfg.User.Name = "Some Maintainer"
+	cfg.User.Email = "someone@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		panic(fmt.Sprintf("unable to set up config: %v", err))
+	}
+
+	return &Repo{
+		GoGit: git.NewGoGit(repo),
+		repo:  repo,
+		clock: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC),
+		prs:   make(map[git.Commit]int),
+	}
+}
+
+// must panics on unexpected errors.
+func (r *Repo) must(err error) {
+	if err != nil {
+		panic(fmt.Sprintf("unable to build test repository: %v", err))
+	}
+}
+
+// tick advances the clock, returning a signature for the new time.
+func (r *Repo) tick(name string) *object.Signature {
+	r.clock = r.clock.Add(time.Minute)
+	return &object.Signature{Name: name, Email: "someone@example.com", When: r.clock}
+}
+
+// files lists the files (by path) in the given commit.
+func (r *Repo) files(commit plumbing.Hash) map[string]plumbing.Hash {
+	files := make(map[string]plumbing.Hash)
+	commitObj, err := r.repo.CommitObject(commit)
+	r.must(err)
+	tree, err := commitObj.Tree()
+	r.must(err)
+	r.must(tree.Files().ForEach(func(file *object.File) error {
+		files[file.Name] = file.Hash
+		return nil
+	}))
+	return files
+}
+
+// commit creates a commit with the given parents, whose files are those of
+// all of its parents (later ones winning), with the given paths changed.
+func (r *Repo) commit(message string, changing []string, parents ...plumbing.Hash) plumbing.Hash {
+	files := make(map[string]plumbing.Hash)
+	for _, parent := range parents {
+		for path, hash := range r.files(parent) {
+			files[path] = hash
+		}
+	}
+	for _, path := range changing {
+		r.edits++
+		files[path] = r.write(plumbing.BlobObject, func(obj plumbing.EncodedObject) error {
+			w, err := obj.Writer()
+			if err != nil {
+				return err
+			}
+			defer w.Close()
+			_, err = fmt.Fprintf(w, "edit %d\n", r.edits)
+			return err
+		})
+	}
+	return r.commitFiles(message, files, parents...)
+}
+
+// commitFiles creates a commit with the given parents and files (by path).
+func (r *Repo) commitFiles(message string, files map[string]plumbing.Hash, parents ...plumbing.Hash) plumbing.Hash {
+	sig := r.tick("Some Contributor")
+	return r.write(plumbing.CommitObject, (&object.Commit{
+		Author:       *sig,
+		Committer:    *sig,
+		Message:      message + "\n",
+		TreeHash:     r.tree(files),
+		ParentHashes: parents,
+	}).Encode)
+}
+
+// tree writes the trees for the given files (by path), returning the root.
+func (r *Repo) tree(files map[string]plumbing.Hash) plumbing.Hash {
+	var entries []object.TreeEntry
+	subdirs := make(map[string]map[string]plumbing.Hash)
+	for path, hash := range files {
+		dir, rest, nested := strings.Cut(path, "/")
+		if !nested {
+			entries = append(entries, object.TreeEntry{Name: path, Mode: filemode.Regular, Hash: hash})
+			continue
+		}
+		if subdirs[dir] == nil {
+			subdirs[dir] = make(map[string]plumbing.Hash)
+		}
+		subdirs[dir][rest] = hash
+	}
+	for dir, subdir := range subdirs {
+		entries = append(entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: r.tree(subdir)})
+	}
+	// git sorts directories as if they ended in a slash
+	sortName := func(entry object.TreeEntry) string {
+		if entry.Mode == filemode.Dir {
+			return entry.Name + "/"
+		}
+		return entry.Name
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return sortName(entries[i]) < sortName(entries[j])
+	})
+	return r.write(plumbing.TreeObject, (&object.Tree{Entries: entries}).Encode)
+}
+
+// write stores an object of the given type with the given contents.
+func (r *Repo) write(objType plumbing.ObjectType, encode func(plumbing.EncodedObject) error) plumbing.Hash {
+	obj := r.repo.Storer.NewEncodedObject()
+	obj.SetType(objType)
+	r.must(encode(obj))
+	hash, err := r.repo.Storer.SetEncodedObject(obj)
+	r.must(err)
+	return hash
+}
+
+// Branch returns the branch with the given name, which starts out with no
+// commits if it doesn't exist yet.  The first branch is checked out.
+func (r *Repo) Branch(name string) *Branch {
+	branch := &Branch{repo: r, name: name}
+	if !r.checkedOut {
+		branch.Checkout()
+	}
+	return branch
+}
+
+// Commit looks up the commit with the given message (the first one found,
+// most recent first, across all branches), for referring to commits made by
+// helpers like MergePR.
+func (r *Repo) Commit(message string) git.Commit {
+	commits, err := r.repo.CommitObjects()
+	r.must(err)
+	var found *object.Commit
+	r.must(commits.ForEach(func(commit *object.Commit) error {
+		if commit.Message == message+"\n" && (found == nil || commit.Committer.When.After(found.Committer.When)) {
+			found = commit
+		}
+		return nil
+	}))
+	if found == nil {
+		panic(fmt.Sprintf("no commit with message %q", message))
+	}
+	return git.Commit(found.Hash.String())
+}
+
+// PRFor returns the number of the PR that the given commit came from (as
+// GitHub would list it for the commit), for commits made by MergePR, SquashPR,
+// and RebasePR.  It returns false for other commits.
+func (r *Repo) PRFor(commit git.Commit) (int, bool) {
+	number, found := r.prs[commit]
+	return number, found
+}
+
+// Branch is a branch in a Repo.
+type Branch struct {
+	repo *Repo
+	name string
+	// changing are the paths changed by each commit made through this
+	// handle (see Changing).
+	changing []string
+}
+
+// refName is the full name of the branch's ref.
+func (b *Branch) refName() plumbing.ReferenceName {
+	return plumbing.NewBranchReferenceName(b.name)
+}
+
+// tip returns the latest commit on the branch, if there is one.
+func (b *Branch) tip() (plumbing.Hash, bool) {
+	ref, err := b.repo.repo.Reference(b.refName(), true)
+	if err != nil {
+		return plumbing.ZeroHash, false
+	}
+	return ref.Hash(), true
+}
+
+// mustTip returns the latest commit on the branch, panicking if there is
+// none.
+func (b *Branch) mustTip() plumbing.Hash {
+	tip, hasTip := b.tip()
+	if !hasTip {
+		panic(fmt.Sprintf("branch %q has no commits yet", b.name))
+	}
+	return tip
+}
+
+// advance points the branch at the given commit.
+func (b *Branch) advance(hash plumbing.Hash) git.Commit {
+	b.repo.must(b.repo.repo.Storer.SetReference(plumbing.NewHashReference(b.refName(), hash)))
+	return git.Commit(hash.String())
+}
+
+// Name returns the name of this branch.
+func (b *Branch) Name() string {
+	return b.name
+}
+
+// Committish implements git.Committish.
+func (b *Branch) Committish() string {
+	return b.name
+}
+
+// Tip returns the latest commit on this branch.
+func (b *Branch) Tip() git.Commit {
+	return git.Commit(b.mustTip().String())
+}
+
+// Changing returns a handle to this branch whose commits (or PRs) each change
+// the given files (paths from the root of the repository).
+func (b *Branch) Changing(paths ...string) *Branch {
+	return &Branch{repo: b.repo, name: b.name, changing: paths}
+}
+
+// Commit adds a regular commit to this branch.  If the branch has no commits
+// yet, this is a root commit.
+func (b *Branch) Commit(message string) git.Commit {
+	return b.advance(b.next(message))
+}
+
+// next creates a regular commit on top of this branch (or a root commit, if
+// the branch has no commits yet), without advancing the branch.
+func (b *Branch) next(message string) plumbing.Hash {
+	tip, hasTip := b.tip()
+	if !hasTip {
+		return b.repo.commit(message, b.changing)
+	}
+	return b.repo.commit(message, b.changing, tip)
+}
+
+// Merge merges the other branch into this one with the given message,
+// creating a merge commit even if a fast-forward would be possible.
+func (b *Branch) Merge(other *Branch, message string) git.Commit {
+	return b.advance(b.repo.commit(message, nil, b.mustTip(), other.mustTip()))
+}
+
+// MergePR simulates merging a GitHub PR with the given title: a commit with
+// the title on a topic branch, merged into this branch with GitHub's usual
+// merge commit message.
+func (b *Branch) MergePR(number int, title string) git.Commit {
+	topic := b.repo.commit(title, b.changing, b.mustTip())
+	b.repo.prs[git.Commit(topic.String())] = number
+	message := fmt.Sprintf("Merge pull request #%d from someone/pr-%d\n\n%s", number, number, title)
+	return b.prCommit(number, b.repo.commit(message, nil, b.mustTip(), topic))
+}
+
+// SquashPR simulates squash-merging a GitHub PR with the given title: a
+// single commit with the title and GitHub's `(#N)` suffix.
+func (b *Branch) SquashPR(number int, title string) git.Commit {
+	return b.prCommit(number, b.next(fmt.Sprintf("%s (#%d)", title, number)))
+}
+
+// RebasePR simulates rebase-merging a GitHub PR made up of commits with the
+// given titles (oldest first).  Like GitHub's rebase merges, the titles are
+// left as-is, so the PR can only be found through PRFor.
+func (b *Branch) RebasePR(number int, titles ...string) git.Commit {
+	var last git.Commit
+	for _, title := range titles {
+		last = b.prCommit(number, b.next(title))
+	}
+	return last
+}
+
+// prCommit points the branch at the given commit, recording that it came
+// from the given PR.
+func (b *Branch) prCommit(number int, hash plumbing.Hash) git.Commit {
+	commit := b.advance(hash)
+	b.repo.prs[commit] = number
+	return commit
+}
+
+// CherryPick copies the given commit onto this branch, like `git
+// cherry-pick -m 1` (so merge commits are copied as regular commits, changing
+// the files changed by whatever they merged to the same contents).
+func (b *Branch) CherryPick(commit git.Commit) git.Commit {
+	orig, err := b.repo.repo.CommitObject(plumbing.NewHash(commit.Committish()))
+	b.repo.must(err)
+	return b.cherryPick(commit, strings.TrimSuffix(orig.Message, "\n"))
+}
+
+// CherryPickX is like CherryPick, but records the original commit in the
+// message like `git cherry-pick -x`.
+func (b *Branch) CherryPickX(commit git.Commit) git.Commit {
+	orig, err := b.repo.repo.CommitObject(plumbing.NewHash(commit.Committish()))
+	b.repo.must(err)
+	message := fmt.Sprintf("%s\n\n(cherry picked from commit %s)", strings.TrimSuffix(orig.Message, "\n"), commit)
+	return b.cherryPick(commit, message)
+}
+
+// cherryPick copies the changes made by the given commit onto this branch
+// with the given message.
+func (b *Branch) cherryPick(commit git.Commit, message string) git.Commit {
+	changed, err := b.repo.ChangedFiles(commit)
+	b.repo.must(err)
+	origFiles := b.repo.files(plumbing.NewHash(commit.Committish()))
+	files := b.repo.files(b.mustTip())
+	for _, path := range changed {
+		if hash, kept := origFiles[path]; kept {
+			files[path] = hash
+		} else {
+			delete(files, path)
+		}
+	}
+	return b.advance(b.repo.commitFiles(message, files, b.mustTip()))
+}
+
+// Fork creates a new branch starting at the tip of this one.
+func (b *Branch) Fork(name string) *Branch {
+	forked := &Branch{repo: b.repo, name: name}
+	forked.advance(b.mustTip())
+	return forked
+}
+
+// Tag creates an annotated tag at the tip of this branch.
+func (b *Branch) Tag(name string) git.Tag {
+	_, err := b.repo.repo.CreateTag(name, b.mustTip(), &gogit.CreateTagOptions{
+		Tagger:  b.repo.tick("Some Maintainer"),
+		Message: name,
+	})
+	b.repo.must(err)
+	return git.Tag(name)
+}
+
+// LightweightTag creates a lightweight tag at the tip of this branch.
+func (b *Branch) LightweightTag(name string) git.Tag {
+	_, err := b.repo.repo.CreateTag(name, b.mustTip(), nil)
+	b.repo.must(err)
+	return git.Tag(name)
+}
+
+// Push simulates pushing this branch to DefaultRemote: it sets the
+// remote-tracking branch to the current tip, and makes it this branch's
+// upstream.  Commits made afterwards are only local until the next Push.
+func (b *Branch) Push() {
+	remoteRef := plumbing.NewRemoteReferenceName(DefaultRemote, b.name)
+	b.repo.must(b.repo.repo.Storer.SetReference(plumbing.NewHashReference(remoteRef, b.mustTip())))
+
+	cfg, err := b.repo.repo.Config()
+	b.repo.must(err)
+	cfg.Branches[b.name] = &config.Branch{Name: b.name, Remote: DefaultRemote, Merge: b.refName()}
+	b.repo.must(b.repo.repo.SetConfig(cfg))
+}
+
+// Reset points this branch at the given commit, like `git reset --hard`
+// (e.g. to leave it behind its upstream).
+func (b *Branch) Reset(commit git.Commit) {
+	b.advance(plumbing.NewHash(commit.Committish()))
+}
+
+// Checkout makes this the current branch.
+func (b *Branch) Checkout() {
+	b.repo.must(b.repo.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, b.refName())))
+	b.repo.checkedOut = true
+}
@@ -0,0 +1,587 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GoGit implements Repository using go-git, so that it works without the git
+// binary (e.g. in minimal containers).
+type GoGit struct {
+	repo *gogit.Repository
+	// ctx stops network operations (like fetches) when cancelled.
+	ctx context.Context
+	// keyring holds the armored public keys used to verify tags.
+	keyring string
+	// reachable caches the results of ancestors by commit, since the same
+	// histories get walked over and over (e.g. once per release tag).  It's
+	// shared between copies of this GoGit.
+	reachable *ancestorCache
+}
+
+// ancestorCache holds the sets of commits reachable from each commit that's
+// been looked up.  A commit's history never changes, so they're never stale.
+type ancestorCache struct {
+	mu   sync.Mutex
+	sets map[plumbing.Hash]map[plumbing.Hash]bool
+}
+
+// OpenGoGit opens the repository containing the given path.
+func OpenGoGit(path string) (*GoGit, error) {
+	repo, err := gogit.PlainOpenWithOptions(path, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open git repository at %q: %w", path, err)
+	}
+	return NewGoGit(repo), nil
+}
+
+// NewGoGit wraps an already-open go-git repository (e.g. an in-memory one).
+func NewGoGit(repo *gogit.Repository) *GoGit {
+	return &GoGit{
+		repo:      repo,
+		ctx:       context.Background(),
+		reachable: &ancestorCache{sets: make(map[plumbing.Hash]map[plumbing.Hash]bool)},
+	}
+}
+
+// WithContext returns a copy of this repository whose network operations
+// (like fetches) stop when the given context is cancelled.  Everything else
+// happens in-process, so it's not affected.
+func (g *GoGit) WithContext(ctx context.Context) *GoGit {
+	res := *g
+	res.ctx = ctx
+	return &res
+}
+
+// WithKeyring returns a copy of this repository that verifies tag signatures
+// against the given armored PGP public keys.  Without a keyring, signed tags
+// are never considered verified.
+func (g *GoGit) WithKeyring(armoredKeys string) *GoGit {
+	res := *g
+	res.keyring = armoredKeys
+	return &res
+}
+
+// upstreamSuffixes are the revision suffixes that refer to the upstream of a
+// branch.
+var upstreamSuffixes = []string{"@{upstream}", "@{u}"}
+
+// resolve resolves a committish to a commit, like `git rev-parse`, including
+// support for the `BRANCH@{u}` upstream syntax.
+func (g *GoGit) resolve(committish string) (*object.Commit, error) {
+	for _, suffix := range upstreamSuffixes {
+		if !strings.HasSuffix(committish, suffix) {
+			continue
+		}
+		upstream, err := g.upstreamRef(strings.TrimSuffix(committish, suffix))
+		if err != nil {
+			return nil, err
+		}
+		committish = upstream.String()
+		break
+	}
+
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(committish))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %q: %w", committish, err)
+	}
+	return g.repo.CommitObject(*hash)
+}
+
+// upstreamConfig returns the configuration for the given branch, if it has an
+// upstream.
+func (g *GoGit) upstreamConfig(branchName string) (*config.Branch, error) {
+	if branchName == "" || branchName == "HEAD" {
+		var err error
+		branchName, err = g.CurrentBranch()
+		if err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := g.repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read repository config: %w", err)
+	}
+	branch, hasBranch := cfg.Branches[branchName]
+	if !hasBranch || branch.Remote == "" || branch.Merge == "" {
+		return nil, fmt.Errorf("no upstream configured for branch %q", branchName)
+	}
+	return branch, nil
+}
+
+// upstreamRef returns the remote-tracking ref for the upstream of the given
+// branch.
+func (g *GoGit) upstreamRef(branchName string) (plumbing.ReferenceName, error) {
+	branch, err := g.upstreamConfig(branchName)
+	if err != nil {
+		return "", err
+	}
+	if branch.Remote == "." {
+		// local upstream
+		return branch.Merge, nil
+	}
+	return plumbing.NewRemoteReferenceName(branch.Remote, branch.Merge.Short()), nil
+}
+
+// ancestors returns the set of commits reachable from the given one
+// (including itself).  The result is cached, so it must not be modified.
+func (g *GoGit) ancestors(from *object.Commit) (map[plumbing.Hash]bool, error) {
+	if g.reachable != nil {
+		g.reachable.mu.Lock()
+		defer g.reachable.mu.Unlock()
+		if res, cached := g.reachable.sets[from.Hash]; cached {
+			return res, nil
+		}
+	}
+
+	res := make(map[plumbing.Hash]bool)
+	err := object.NewCommitPreorderIter(from, nil, nil).ForEach(func(commit *object.Commit) error {
+		res[commit.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if g.reachable != nil {
+		g.reachable.sets[from.Hash] = res
+	}
+	return res, nil
+}
+
+// VersionTags implements Git, like `git tag --list PREFIXv[0-9]*`.
+func (g *GoGit) VersionTags(prefix string) ([]Tag, error) {
+	tags, err := g.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tags: %w", err)
+	}
+	accept := versionTagFilter(prefix)
+	var res []Tag
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		if name := ref.Name().Short(); accept(name) {
+			res = append(res, Tag(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tags: %w", err)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res, nil
+}
+
+// MergedTags implements Git, like `git tag --merged`.
+func (g *GoGit) MergedTags(into Committish) ([]Tag, error) {
+	start, err := g.resolve(into.Committish())
+	if err != nil {
+		return nil, err
+	}
+	reachable, err := g.ancestors(start)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits reachable from %q: %w", into.Committish(), err)
+	}
+
+	tags, err := g.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tags: %w", err)
+	}
+	var res []Tag
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		commitHash := ref.Hash()
+		if tag, err := g.repo.TagObject(ref.Hash()); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				// tags of non-commits aren't merged into anything
+				return nil
+			}
+			commitHash = commit.Hash
+		}
+		if reachable[commitHash] {
+			res = append(res, Tag(ref.Name().Short()))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to check tags: %w", err)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res, nil
+}
+
+// versionTagFilter accepts tag names matching PREFIXv[0-9]*.
+func versionTagFilter(prefix string) func(name string) bool {
+	return func(name string) bool {
+		rest := strings.TrimPrefix(name, prefix)
+		return strings.HasPrefix(name, prefix) && len(rest) > 1 && rest[0] == 'v' && rest[1] >= '0' && rest[1] <= '9'
+	}
+}
+
+// FirstCommit implements Git.  If the branch has several root commits, the
+// most recent one is returned.
+func (g *GoGit) FirstCommit(branchName string) (Commit, error) {
+	head, err := g.resolve(branchName)
+	if err != nil {
+		return "", err
+	}
+	var roots []*object.Commit
+	err = object.NewCommitPreorderIter(head, nil, nil).ForEach(func(commit *object.Commit) error {
+		if commit.NumParents() == 0 {
+			roots = append(roots, commit)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to list commits on %q: %w", branchName, err)
+	}
+	if len(roots) == 0 {
+		return "", fmt.Errorf("no root commits found on %q", branchName)
+	}
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].Committer.When.After(roots[j].Committer.When)
+	})
+	return Commit(roots[0].Hash.String()), nil
+}
+
+// HasUpstream implements Git.  Like the git binary version, this expects
+// something like `BRANCH@{u}`, and returns an error if it can't be resolved.
+func (g *GoGit) HasUpstream(branchName string) error {
+	_, err := g.resolve(branchName)
+	return err
+}
+
+// MergeCommitsBetween implements Git, like `git log START..END --merges`.
+func (g *GoGit) MergeCommitsBetween(start, end Committish) ([]CommitRecord, error) {
+	startCommit, err := g.resolve(start.Committish())
+	if err != nil {
+		return nil, err
+	}
+	endCommit, err := g.resolve(end.Committish())
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := g.ancestors(startCommit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits reachable from %q: %w", start.Committish(), err)
+	}
+
+	var merges []*object.Commit
+	// the iterator doesn't walk past excluded commits
+	err = object.NewCommitPreorderIter(endCommit, excluded, nil).ForEach(func(commit *object.Commit) error {
+		if commit.NumParents() > 1 {
+			merges = append(merges, commit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits between %q and %q: %w", start.Committish(), end.Committish(), err)
+	}
+	sort.SliceStable(merges, func(i, j int) bool {
+		return merges[i].Committer.When.After(merges[j].Committer.When)
+	})
+
+	return records(merges), nil
+}
+
+// FirstParentCommitsBetween implements Git, like `git log START..END
+// --first-parent`.
+func (g *GoGit) FirstParentCommitsBetween(start, end Committish) ([]CommitRecord, error) {
+	startCommit, err := g.resolve(start.Committish())
+	if err != nil {
+		return nil, err
+	}
+	commit, err := g.resolve(end.Committish())
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := g.ancestors(startCommit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list commits reachable from %q: %w", start.Committish(), err)
+	}
+
+	var commits []*object.Commit
+	for !excluded[commit.Hash] {
+		commits = append(commits, commit)
+		if commit.NumParents() == 0 {
+			break
+		}
+		commit, err = commit.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list commits between %q and %q: %w", start.Committish(), end.Committish(), err)
+		}
+	}
+	return records(commits), nil
+}
+
+// ChangedFiles implements Git.
+func (g *GoGit) ChangedFiles(commit Commit) ([]string, error) {
+	changes, err := g.firstParentChanges(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, change := range changes {
+		// without rename detection, additions & deletions only have one side
+		name := change.To.Name
+		if name == "" {
+			name = change.From.Name
+		}
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (g *GoGit) PatchID(commit Commit) (string, error) {
+	changes, err := g.firstParentChanges(commit)
+	if err != nil {
+		return "", err
+	}
+	patch, err := changes.PatchContext(g.ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to compute the patch for %q: %w", commit.Committish(), err)
+	}
+	return patchID(patch.String()), nil
+}
+
+// firstParentChanges diffs the given commit against its first parent (or an
+// empty tree, for root commits), without rename detection.
+func (g *GoGit) firstParentChanges(commit Commit) (object.Changes, error) {
+	commitObj, err := g.resolve(commit.Committish())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commitObj.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read tree of %q: %w", commit.Committish(), err)
+	}
+	// root commits are compared to an empty tree
+	var parentTree *object.Tree
+	if commitObj.NumParents() > 0 {
+		parent, err := commitObj.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read parent of %q: %w", commit.Committish(), err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("unable to read tree of %q: %w", parent.Hash, err)
+		}
+	}
+	changes, err := object.DiffTreeWithOptions(g.ctx, parentTree, tree, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to diff %q against its parent: %w", commit.Committish(), err)
+	}
+	return changes, nil
+}
+
+// records converts the given commits to commit records.
+func records(commits []*object.Commit) []CommitRecord {
+	var res []CommitRecord
+	for _, commit := range commits {
+		record := CommitRecord{
+			SHA:         Commit(commit.Hash.String()),
+			AuthorName:  commit.Author.Name,
+			AuthorEmail: commit.Author.Email,
+			Date:        commit.Committer.When,
+		}
+		for _, parent := range commit.ParentHashes {
+			record.Parents = append(record.Parents, Commit(parent.String()))
+		}
+		record.setMessage(commit.Message)
+		res = append(res, record)
+	}
+	return res
+}
+
+// VerifyTag implements Git.  Only PGP signatures are supported.
+func (g *GoGit) VerifyTag(tag Tag) (TagSignature, error) {
+	ref, err := g.repo.Tag(string(tag))
+	if err != nil {
+		return TagSignature{}, fmt.Errorf("unable to find tag %q: %w", tag, err)
+	}
+	tagObj, err := g.repo.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		// lightweight
+		return TagSignature{}, nil
+	}
+	if err != nil {
+		return TagSignature{}, fmt.Errorf("unable to read tag %q: %w", tag, err)
+	}
+
+	res := TagSignature{Annotated: true, Signed: tagObj.PGPSignature != ""}
+	if !res.Signed || g.keyring == "" {
+		return res, nil
+	}
+	signer, err := tagObj.Verify(g.keyring)
+	if err != nil {
+		return res, nil
+	}
+	res.Verified = true
+	if identity := signer.PrimaryIdentity(); identity != nil {
+		res.Signer = identity.Name
+	}
+	res.Fingerprints = signingFingerprints(signer, tagObj.PGPSignature)
+	return res, nil
+}
+
+// signingFingerprints lists the fingerprints of the key from the given entity
+// that made the given (verified) signature, and of the entity's primary key,
+// if that was a subkey, like gpg reports them.
+func signingFingerprints(signer *openpgp.Entity, signature string) []string {
+	primary := strings.ToUpper(hex.EncodeToString(signer.PrimaryKey.Fingerprint))
+	issuer, ok := signatureIssuer(signature)
+	if !ok || issuer == signer.PrimaryKey.KeyId {
+		return []string{primary}
+	}
+	for _, subkey := range signer.Subkeys {
+		if subkey.PublicKey.KeyId == issuer {
+			return []string{strings.ToUpper(hex.EncodeToString(subkey.PublicKey.Fingerprint)), primary}
+		}
+	}
+	return []string{primary}
+}
+
+// signatureIssuer finds the ID of the key that made the given armored
+// signature.
+func signatureIssuer(signature string) (uint64, bool) {
+	block, err := armor.Decode(strings.NewReader(signature))
+	if err != nil {
+		return 0, false
+	}
+	pkt, err := packet.Read(block.Body)
+	if err != nil {
+		return 0, false
+	}
+	sig, isSig := pkt.(*packet.Signature)
+	if !isSig || sig.IssuerKeyId == nil {
+		return 0, false
+	}
+	return *sig.IssuerKeyId, true
+}
+
+// CurrentBranch implements Repository.  Like git, it returns "HEAD" if HEAD
+// is detached.
+func (g *GoGit) CurrentBranch() (string, error) {
+	head, err := g.repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine current branch from HEAD: %w", err)
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "HEAD", nil
+	}
+	return head.Target().Short(), nil
+}
+
+// RemoteForUpstreamFor implements Repository.
+func (g *GoGit) RemoteForUpstreamFor(branchName string) (string, error) {
+	branch, err := g.upstreamConfig(branchName)
+	if err != nil {
+		return "", fmt.Errorf("no upstream/remote found: %w", err)
+	}
+	return branch.Remote, nil
+}
+
+// URLForRemote implements Repository.
+func (g *GoGit) URLForRemote(remote string) (string, error) {
+	res, err := g.repo.Remote(remote)
+	if err != nil {
+		return "", fmt.Errorf("unable to find remote %q: %w", remote, err)
+	}
+	if len(res.Config().URLs) == 0 {
+		return "", fmt.Errorf("remote %q has no URLs", remote)
+	}
+	return res.Config().URLs[0], nil
+}
+
+// Fetch implements Repository.
+func (g *GoGit) Fetch(remote string) error {
+	err := g.repo.FetchContext(g.ctx, &gogit.FetchOptions{RemoteName: remote, Tags: gogit.AllTags})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("unable to fetch remote %q: %w", remote, err)
+	}
+	return nil
+}
+
+// IsAncestor implements Repository.
+func (g *GoGit) IsAncestor(ancestor, descendant Committish) (bool, error) {
+	ancestorCommit, err := g.resolve(ancestor.Committish())
+	if err != nil {
+		return false, err
+	}
+	descendantCommit, err := g.resolve(descendant.Committish())
+	if err != nil {
+		return false, err
+	}
+	reachable, err := g.ancestors(descendantCommit)
+	if err != nil {
+		return false, fmt.Errorf("unable to list commits reachable from %q: %w", descendant.Committish(), err)
+	}
+	return reachable[ancestorCommit.Hash], nil
+}
+
+// HasTag implements Repository.
+func (g *GoGit) HasTag(tag Tag) (bool, error) {
+	_, err := g.repo.Tag(string(tag))
+	if errors.Is(err, gogit.ErrTagNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to look up tag %q: %w", tag, err)
+	}
+	return true, nil
+}
+
+// CreateTag implements Repository.  The tagger is the user from the git
+// config.  Signing isn't supported, since there's no access to the user's
+// private keys.
+func (g *GoGit) CreateTag(tag Tag, target Committish, message string, sign bool) error {
+	if sign {
+		return fmt.Errorf("unable to create tag %q: signing tags isn't supported without the git binary", tag)
+	}
+	commit, err := g.resolve(target.Committish())
+	if err != nil {
+		return err
+	}
+	_, err = g.repo.CreateTag(string(tag), commit.Hash, &gogit.CreateTagOptions{Message: tagMessage(message)})
+	if err != nil {
+		return fmt.Errorf("unable to create tag %q: %w", tag, err)
+	}
+	return nil
+}
+
+// PushTag implements Repository.
+func (g *GoGit) PushTag(remote string, tag Tag) error {
+	refSpec := config.RefSpec(tagRef(tag) + ":" + tagRef(tag))
+	err := g.repo.PushContext(g.ctx, &gogit.PushOptions{RemoteName: remote, RefSpecs: []config.RefSpec{refSpec}})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("unable to push tag %q to remote %q: %w", tag, remote, err)
+	}
+	return nil
+}
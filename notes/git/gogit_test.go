@@ -0,0 +1,488 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/git"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git/gittest"
+)
+
+// fixture builds up a repository on disk, one commit at a time.
+type fixture struct {
+	repo  *gogit.Repository
+	clock time.Time
+}
+
+func (f *fixture) commit(msg string, parents ...plumbing.Hash) plumbing.Hash {
+	f.clock = f.clock.Add(time.Minute)
+	sig := object.Signature{Name: "Some Contributor", Email: "contrib@example.com", When: f.clock}
+
+	tree := f.repo.Storer.NewEncodedObject()
+	Expect((&object.Tree{}).Encode(tree)).To(Succeed())
+	treeHash, err := f.repo.Storer.SetEncodedObject(tree)
+	Expect(err).NotTo(HaveOccurred())
+
+	obj := f.repo.Storer.NewEncodedObject()
+	Expect((&object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      msg,
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	}).Encode(obj)).To(Succeed())
+	hash, err := f.repo.Storer.SetEncodedObject(obj)
+	Expect(err).NotTo(HaveOccurred())
+	return hash
+}
+
+// shas extracts the SHAs from a list of commit records.
+func shas(commits []CommitRecord) []Commit {
+	res := make([]Commit, len(commits))
+	for i, commit := range commits {
+		res[i] = commit.SHA
+	}
+	return res
+}
+
+// patchID computes the patch ID of the given commit, failing the test on
+// errors.
+func patchID(repo Git, commit Commit) string {
+	id, err := repo.PatchID(commit)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	return id
+}
+
+func (f *fixture) ref(name plumbing.ReferenceName, hash plumbing.Hash) {
+	Expect(f.repo.Storer.SetReference(plumbing.NewHashReference(name, hash))).To(Succeed())
+}
+
+func (f *fixture) tag(name string, hash plumbing.Hash, annotated bool) {
+	var opts *gogit.CreateTagOptions
+	if annotated {
+		f.clock = f.clock.Add(time.Minute)
+		opts = &gogit.CreateTagOptions{
+			Tagger:  &object.Signature{Name: "Some Maintainer", Email: "maint@example.com", When: f.clock},
+			Message: name,
+		}
+	}
+	_, err := f.repo.CreateTag(name, hash, opts)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+var _ = Describe("The go-git backend", func() {
+	var (
+		dir     string
+		fix     *fixture
+		gitImpl *GoGit
+
+		root, fix1, merge1, feat2, merge2, relFix, relMerge plumbing.Hash
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "notes-gogit")
+		Expect(err).NotTo(HaveOccurred())
+
+		repo, err := gogit.PlainInit(dir, false)
+		Expect(err).NotTo(HaveOccurred())
+		fix = &fixture{repo: repo, clock: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+
+		// main: root -- merge1 ------- merge2
+		//           \   /             /
+		//            fix1 -- feat2 --'
+		//                 \
+		// release-0.1:     relFix -- relMerge (merge of relFix onto merge1)
+		root = fix.commit("Initial commit\n")
+		fix.tag("v0.1.0", root, true)
+		fix1 = fix.commit(":bug: Fix leaking goroutine\n", root)
+		merge1 = fix.commit("Merge pull request #1161 from contrib/fix-leak\n\n:bug: Fix leaking goroutine\n", root, fix1)
+		relFix = fix.commit(":bug: Backport the fix\n", fix1)
+		relMerge = fix.commit("Merge pull request #1170 from contrib/backport\n\n:bug: Backport the fix\n", merge1, relFix)
+		fix.tag("v0.1.1", relMerge, true)
+		feat2 = fix.commit(":sparkles: Add Foo field\n", fix1)
+		merge2 = fix.commit("Merge pull request #1162 from contrib/foo\n\n:sparkles: Add Foo field\n", merge1, feat2)
+
+		fix.ref(plumbing.NewBranchReferenceName("main"), merge2)
+		fix.ref(plumbing.NewBranchReferenceName("release-0.1"), relMerge)
+		fix.ref(plumbing.NewRemoteReferenceName("origin", "main"), merge2)
+		Expect(repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main")))).To(Succeed())
+
+		cfg, err := repo.Config()
+		Expect(err).NotTo(HaveOccurred())
+		cfg.Remotes["origin"] = &config.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/kubernetes-sigs/controller-runtime.git"}}
+		cfg.Branches["main"] = &config.Branch{Name: "main", Remote: "origin", Merge: plumbing.NewBranchReferenceName("main")}
+		Expect(repo.SetConfig(cfg)).To(Succeed())
+
+		Expect(os.MkdirAll(filepath.Join(dir, "some", "subdir"), 0755)).To(Succeed())
+		gitImpl, err = OpenGoGit(filepath.Join(dir, "some", "subdir"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should list the tags reachable from a commit", func() {
+		Expect(gitImpl.MergedTags(SomeCommittish("main"))).To(Equal([]Tag{"v0.1.0"}))
+		Expect(gitImpl.MergedTags(SomeCommittish("release-0.1"))).To(Equal([]Tag{"v0.1.0", "v0.1.1"}))
+		Expect(gitImpl.MergedTags(Commit(fix.commit("Unrelated history\n").String()))).To(BeEmpty())
+	})
+
+	It("should find the first commit on a branch", func() {
+		Expect(gitImpl.FirstCommit("release-0.1")).To(Equal(Commit(root.String())))
+	})
+
+	It("should check for upstreams using the @{u} syntax", func() {
+		Expect(gitImpl.HasUpstream("main@{u}")).To(Succeed())
+		Expect(gitImpl.HasUpstream("release-0.1@{u}")).NotTo(Succeed())
+	})
+
+	It("should list merge commits between two points, most recent first", func() {
+		Expect(gitImpl.MergeCommitsBetween(Tag("v0.1.0"), SomeCommittish("main@{u}"))).To(WithTransform(shas, Equal(
+			[]Commit{Commit(merge2.String()), Commit(merge1.String())})))
+
+		Expect(gitImpl.MergeCommitsBetween(Tag("v0.1.1"), SomeCommittish("main"))).To(WithTransform(shas, Equal(
+			[]Commit{Commit(merge2.String())})))
+	})
+
+	It("should list first-parent commits between two points, most recent first", func() {
+		Expect(gitImpl.FirstParentCommitsBetween(Tag("v0.1.0"), SomeCommittish("main"))).To(WithTransform(shas, Equal(
+			[]Commit{Commit(merge2.String()), Commit(merge1.String())})))
+
+		Expect(gitImpl.FirstParentCommitsBetween(Commit(merge1.String()), SomeCommittish("release-0.1"))).To(WithTransform(shas, Equal(
+			[]Commit{Commit(relMerge.String())})))
+	})
+
+	It("should fill out commit records with metadata & the parsed message", func() {
+		commits, err := gitImpl.MergeCommitsBetween(Tag("v0.1.1"), SomeCommittish("main"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commits).To(HaveLen(1))
+
+		record := commits[0]
+		Expect(record.Parents).To(Equal([]Commit{Commit(merge1.String()), Commit(feat2.String())}))
+		Expect(record.IsMerge()).To(BeTrue())
+		Expect(record.AuthorName).To(Equal("Some Contributor"))
+		Expect(record.AuthorEmail).To(Equal("contrib@example.com"))
+		Expect(record.Date).To(BeTemporally("==", time.Date(2020, 10, 1, 12, 9, 0, 0, time.UTC)))
+		Expect(record.Subject).To(Equal("Merge pull request #1162 from contrib/foo"))
+		Expect(record.Body).To(Equal(":sparkles: Add Foo field"))
+		Expect(record.Trailers).To(BeEmpty())
+	})
+
+	It("should split subjects, bodies, and trailers like git does", func() {
+		tricky := fix.commit("Squash a PR\nwith a wrapped subject (#1180)\n\n"+
+			"commit 0123456789012345678901234567890123456789\n\n\n"+
+			"Some more details.\n\n"+
+			"Co-authored-by: Some One <someone@example.com>\n"+
+			"Signed-off-by: Some Contributor\n  <contrib@example.com>\n", merge2)
+		fix.ref(plumbing.NewBranchReferenceName("main"), tricky)
+
+		commits, err := gitImpl.FirstParentCommitsBetween(Commit(merge2.String()), SomeCommittish("main"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(commits).To(HaveLen(1))
+
+		record := commits[0]
+		Expect(record.IsMerge()).To(BeFalse())
+		Expect(record.Subject).To(Equal("Squash a PR with a wrapped subject (#1180)"))
+		Expect(record.Body).To(Equal("commit 0123456789012345678901234567890123456789\n\n\n" +
+			"Some more details.\n\n" +
+			"Co-authored-by: Some One <someone@example.com>\n" +
+			"Signed-off-by: Some Contributor\n  <contrib@example.com>"))
+		Expect(record.Trailers).To(Equal([]Trailer{
+			{Key: "Co-authored-by", Value: "Some One <someone@example.com>"},
+			{Key: "Signed-off-by", Value: "Some Contributor <contrib@example.com>"},
+		}))
+	})
+
+	It("should find the current branch", func() {
+		Expect(gitImpl.CurrentBranch()).To(Equal("main"))
+	})
+
+	It("should find remotes & their URLs from the repository config", func() {
+		Expect(gitImpl.RemoteForUpstreamFor("main")).To(Equal("origin"))
+		Expect(gitImpl.URLForRemote("origin")).To(Equal("https://github.com/kubernetes-sigs/controller-runtime.git"))
+
+		_, err := gitImpl.RemoteForUpstreamFor("release-0.1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("compared to the git binary", func() {
+		var actual Repository
+		BeforeEach(func() {
+			if _, err := exec.LookPath("git"); err != nil {
+				Skip("git binary not available")
+			}
+			actual = NewActual(context.Background(), filepath.Join(dir, "some", "subdir"))
+		})
+
+		It("should produce the same results when run against another directory", func() {
+			must := func(val interface{}, err error) interface{} {
+				Expect(err).NotTo(HaveOccurred())
+				return val
+			}
+			for _, committish := range []Committish{SomeCommittish("main"), SomeCommittish("release-0.1"), Commit(feat2.String())} {
+				Expect(actual.MergedTags(committish)).To(Equal(must(gitImpl.MergedTags(committish))))
+			}
+			Expect(actual.FirstCommit("release-0.1")).To(Equal(must(gitImpl.FirstCommit("release-0.1"))))
+			Expect(actual.CurrentBranch()).To(Equal(must(gitImpl.CurrentBranch())))
+			Expect(actual.RemoteForUpstreamFor("main")).To(Equal(must(gitImpl.RemoteForUpstreamFor("main"))))
+			Expect(actual.URLForRemote("origin")).To(Equal(must(gitImpl.URLForRemote("origin"))))
+			Expect(actual.HasUpstream("main@{u}")).To(Succeed())
+			Expect(actual.HasUpstream("release-0.1@{u}")).NotTo(Succeed())
+
+			expected, err := gitImpl.MergeCommitsBetween(Tag("v0.1.0"), SomeCommittish("main"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual.MergeCommitsBetween(Tag("v0.1.0"), SomeCommittish("main"))).To(WithTransform(shas, Equal(shas(expected))))
+		})
+
+		It("should use the given environment", func() {
+			elsewhere, err := os.MkdirTemp("", "notes-elsewhere")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(elsewhere)
+
+			actual = NewActual(context.Background(), elsewhere, "GIT_DIR="+filepath.Join(dir, ".git"))
+			Expect(actual.CurrentBranch()).To(Equal("main"))
+		})
+
+		It("should stop when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			actual = NewActual(ctx, dir)
+
+			_, err := actual.CurrentBranch()
+			Expect(err).To(MatchError(context.Canceled))
+		})
+	})
+})
+
+var _ = Describe("Separately-tagged modules", func() {
+	var (
+		repo                  *gittest.Repo
+		initial, envtestFix   Commit
+		clientFeat, mergeBase Commit
+	)
+	BeforeEach(func() {
+		repo = gittest.NewRepo()
+		main := repo.Branch("main")
+		initial = main.Changing("go.mod", "main.go").Commit("Initial commit")
+		main.Tag("v0.1.0")
+		mergeBase = main.Changing("tools/setup-envtest/go.mod").Commit("Add setup-envtest")
+		main.Tag("tools/setup-envtest/v0.1.0")
+		envtestFix = main.Changing("tools/setup-envtest/main.go", "README.md").MergePR(1170, ":bug: Fix envtest downloads")
+		clientFeat = main.Changing("pkg/client/client.go").MergePR(1171, ":sparkles: Add a client option")
+	})
+
+	It("should list the files changed by commits, compared to their first parent", func() {
+		Expect(repo.ChangedFiles(initial)).To(Equal([]string{"go.mod", "main.go"}))
+		Expect(repo.ChangedFiles(mergeBase)).To(Equal([]string{"tools/setup-envtest/go.mod"}))
+		Expect(repo.ChangedFiles(envtestFix)).To(Equal([]string{"README.md", "tools/setup-envtest/main.go"}))
+		Expect(repo.ChangedFiles(clientFeat)).To(Equal([]string{"pkg/client/client.go"}))
+	})
+
+	It("should give cherry-picks of the same change the same patch ID", func() {
+		rel := repo.Branch("release-0.1")
+		rel.Reset(mergeBase)
+		picked := rel.CherryPick(envtestFix)
+		Expect(patchID(repo, picked)).NotTo(BeEmpty())
+		Expect(patchID(repo, picked)).To(Equal(patchID(repo, envtestFix)))
+		Expect(patchID(repo, rel.CherryPickX(clientFeat))).To(Equal(patchID(repo, clientFeat)))
+		Expect(patchID(repo, clientFeat)).NotTo(Equal(patchID(repo, envtestFix)))
+		Expect(patchID(repo, rel.Commit("Nothing to see here"))).To(BeEmpty())
+	})
+
+	It("should only consider version tags with the given prefix", func() {
+		Expect(repo.VersionTags("tools/setup-envtest/")).To(Equal([]Tag{"tools/setup-envtest/v0.1.0"}))
+		Expect(repo.VersionTags("")).To(Equal([]Tag{"v0.1.0"}))
+		Expect(repo.VersionTags("tools/")).To(BeEmpty())
+	})
+
+	Context("with tags on other branches", func() {
+		BeforeEach(func() {
+			rel1 := repo.Branch("main").Fork("release-0.1")
+			rel1.Commit("Backport a fix")
+			rel1.Tag("v0.1.1")
+			rel1.Tag("not-a-version")
+		})
+
+		It("should list version tags with the given prefix, reachable or not", func() {
+			Expect(repo.VersionTags("")).To(Equal([]Tag{"v0.1.0", "v0.1.1"}))
+			Expect(repo.VersionTags("tools/setup-envtest/")).To(Equal([]Tag{"tools/setup-envtest/v0.1.0"}))
+			Expect(repo.VersionTags("tools/")).To(BeEmpty())
+		})
+
+		It("should list all the tags merged into a commit", func() {
+			Expect(repo.MergedTags(SomeCommittish("main"))).To(Equal([]Tag{"tools/setup-envtest/v0.1.0", "v0.1.0"}))
+			Expect(repo.MergedTags(SomeCommittish("release-0.1"))).To(Equal([]Tag{"not-a-version", "tools/setup-envtest/v0.1.0", "v0.1.0", "v0.1.1"}))
+			Expect(repo.MergedTags(initial)).To(Equal([]Tag{"v0.1.0"}))
+		})
+
+		It("should keep finding the right tags & ancestors as history grows", func() {
+			Expect(repo.MergedTags(SomeCommittish("release-0.1"))).To(HaveLen(4))
+			Expect(repo.IsAncestor(clientFeat, SomeCommittish("release-0.1"))).To(BeTrue())
+
+			rel1 := repo.Branch("release-0.1")
+			rel1.Commit("Backport another fix")
+			rel1.Tag("v0.1.2")
+			Expect(repo.MergedTags(SomeCommittish("release-0.1"))).To(Equal([]Tag{"not-a-version", "tools/setup-envtest/v0.1.0", "v0.1.0", "v0.1.1", "v0.1.2"}))
+			Expect(repo.MergedTags(SomeCommittish("main"))).To(Equal([]Tag{"tools/setup-envtest/v0.1.0", "v0.1.0"}))
+			Expect(repo.IsAncestor(SomeCommittish("v0.1.1"), SomeCommittish("release-0.1"))).To(BeTrue())
+			Expect(repo.IsAncestor(SomeCommittish("release-0.1"), SomeCommittish("v0.1.1"))).To(BeFalse())
+		})
+	})
+
+	Context("compared to the git binary", func() {
+		var (
+			dir    string
+			actual Repository
+			goGit  *GoGit
+			run    func(args ...string) string
+			write  func(path, contents string)
+		)
+		BeforeEach(func() {
+			if _, err := exec.LookPath("git"); err != nil {
+				Skip("git binary not available")
+			}
+			var err error
+			dir, err = os.MkdirTemp("", "notes-modules")
+			Expect(err).NotTo(HaveOccurred())
+
+			run = func(args ...string) string {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = dir
+				cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Some Contributor", "GIT_AUTHOR_EMAIL=contrib@example.com", "GIT_COMMITTER_NAME=Some Contributor", "GIT_COMMITTER_EMAIL=contrib@example.com")
+				out, err := cmd.CombinedOutput()
+				ExpectWithOffset(1, err).NotTo(HaveOccurred(), string(out))
+				return strings.TrimSpace(string(out))
+			}
+			write = func(path, contents string) {
+				Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, path), []byte(contents), 0644)).To(Succeed())
+			}
+
+			run("init", "-q", "-b", "main")
+			write("go.mod", "module example.com/root\n")
+			run("add", ".")
+			run("commit", "-q", "-m", "Initial commit")
+			run("tag", "v0.1.0")
+			write("tools/setup-envtest/go.mod", "module example.com/root/tools/setup-envtest\n")
+			run("add", ".")
+			run("commit", "-q", "-m", "Add setup-envtest")
+			run("tag", "tools/setup-envtest/v0.1.0")
+			run("checkout", "-q", "-b", "fix")
+			write("tools/setup-envtest/main.go", "package main\n")
+			run("add", ".")
+			run("commit", "-q", "-m", ":bug: Fix envtest downloads")
+			run("checkout", "-q", "main")
+			write("README.md", "# root\n")
+			run("add", ".")
+			run("commit", "-q", "-m", ":book: Add a README")
+			run("merge", "-q", "--no-ff", "-m", "Merge pull request #1170 from contrib/fix", "fix")
+			run("mv", "go.mod", "go.mod.orig")
+			run("commit", "-q", "-m", "Rename go.mod")
+
+			actual = NewActual(context.Background(), dir)
+			goGit, err = OpenGoGit(dir)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should list the same changed files", func() {
+			commits, err := actual.FirstParentCommitsBetween(SomeCommittish("v0.1.0"), SomeCommittish("main"))
+			Expect(err).NotTo(HaveOccurred())
+			// the root commit, too
+			commits = append(commits, CommitRecord{SHA: Commit("v0.1.0"), Subject: "Initial commit"})
+			for _, commit := range commits {
+				expected, err := actual.ChangedFiles(commit.SHA)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(goGit.ChangedFiles(commit.SHA)).To(Equal(expected), "for %s", commit.Subject)
+			}
+			Expect(actual.ChangedFiles(Commit("main~1"))).To(Equal([]string{"tools/setup-envtest/main.go"}))
+			Expect(actual.ChangedFiles(Commit("main"))).To(Equal([]string{"go.mod", "go.mod.orig"}))
+		})
+
+		It("should compute the same patch IDs", func() {
+			commits, err := actual.FirstParentCommitsBetween(SomeCommittish("v0.1.0"), SomeCommittish("main"))
+			Expect(err).NotTo(HaveOccurred())
+			commits = append(commits, CommitRecord{SHA: Commit("v0.1.0"), Subject: "Initial commit"}, CommitRecord{SHA: Commit("fix"), Subject: ":bug: Fix envtest downloads"})
+			for _, commit := range commits {
+				expected, err := actual.PatchID(commit.SHA)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(expected).NotTo(BeEmpty(), "for %s", commit.Subject)
+				Expect(goGit.PatchID(commit.SHA)).To(Equal(expected), "for %s", commit.Subject)
+			}
+			// the merge changes what the PR did
+			Expect(patchID(actual, Commit("main~1"))).To(Equal(patchID(actual, Commit("fix"))))
+		})
+
+		It("should count removed lines that look like file headers as changes", func() {
+			write("schema.sql", "-- the users\nCREATE TABLE users;\n-- the groups\nCREATE TABLE groups;\n")
+			run("add", ".")
+			run("commit", "-q", "-m", "Add a schema")
+			write("schema.sql", "CREATE TABLE users;\n-- the groups\nCREATE TABLE groups;\n")
+			run("commit", "-q", "-a", "-m", "Drop the users comment")
+			dropUsers := Commit(run("rev-parse", "HEAD"))
+			write("schema.sql", "CREATE TABLE users;\nCREATE TABLE groups;\n")
+			run("commit", "-q", "-a", "-m", "Drop the groups comment")
+			dropGroups := Commit(run("rev-parse", "HEAD"))
+
+			for _, commit := range []Commit{dropUsers, dropGroups} {
+				Expect(patchID(actual, commit)).NotTo(BeEmpty())
+				Expect(patchID(goGit, commit)).To(Equal(patchID(actual, commit)))
+			}
+			gitPatchID := func(commit Commit) string {
+				cmd := exec.Command("git", "patch-id", "--stable")
+				cmd.Dir = dir
+				cmd.Stdin = strings.NewReader(run("diff-tree", "-p", string(commit)) + "\n")
+				out, err := cmd.Output()
+				Expect(err).NotTo(HaveOccurred())
+				return strings.Fields(string(out))[0]
+			}
+			// like `git patch-id`, different removals are different changes
+			Expect(gitPatchID(dropUsers)).NotTo(Equal(gitPatchID(dropGroups)))
+			Expect(patchID(actual, dropUsers)).NotTo(Equal(patchID(actual, dropGroups)))
+		})
+
+		It("should find the same prefixed version tags", func() {
+			for _, prefix := range []string{"", "tools/setup-envtest/", "tools/"} {
+				expectedTags, err := actual.VersionTags(prefix)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(goGit.VersionTags(prefix)).To(Equal(expectedTags))
+			}
+
+			for _, into := range []string{"main", "main~2", "v0.1.0"} {
+				expected, err := actual.MergedTags(SomeCommittish(into))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(goGit.MergedTags(SomeCommittish(into))).To(Equal(expected), "for %s", into)
+			}
+		})
+	})
+})
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// TagSignature describes whether (and by whom) a tag is signed.
+type TagSignature struct {
+	// Annotated indicates that this is an annotated tag, as opposed to a
+	// lightweight one (which can't be signed).
+	Annotated bool
+	// Signed indicates that the tag carries a signature.
+	Signed bool
+	// Verified indicates that the signature was checked against the
+	// keyring, and is good.
+	Verified bool
+
+	// Signer is the user ID of the key that made a verified signature.
+	Signer string
+	// Fingerprints are the fingerprints of the key that made a verified
+	// signature, and of its primary key, if it's a subkey (uppercase hex).
+	Fingerprints []string
+}
+
+// signatureMarker starts the signature in a signed tag object (PGP, SSH, or
+// X.509).
+const signatureMarker = "\n-----BEGIN "
+
+// tagRef is the full name of the given tag, so that it's not confused with a
+// branch of the same name.
+func tagRef(tag Tag) string {
+	return "refs/tags/" + string(tag)
+}
+
+func (g actualGit) VerifyTag(tag Tag) (TagSignature, error) {
+	objType, err := g.output("cat-file", "-t", tagRef(tag))
+	if err != nil {
+		return TagSignature{}, fmt.Errorf("unable to find tag %q: %w", tag, err)
+	}
+	if strings.TrimSpace(objType) != "tag" {
+		return TagSignature{}, nil
+	}
+
+	res := TagSignature{Annotated: true}
+	contents, err := g.output("cat-file", "tag", tagRef(tag))
+	if err != nil {
+		return TagSignature{}, fmt.Errorf("unable to read tag %q: %w", tag, err)
+	}
+	if !strings.Contains(contents, signatureMarker) {
+		return res, nil
+	}
+	res.Signed = true
+
+	// verify-tag exits non-zero for signatures it can't verify, but the
+	// status lines say why, so look at them instead.
+	cmd := g.command("verify-tag", "--raw", tagRef(tag))
+	var status bytes.Buffer
+	cmd.Stderr = &status
+	runErr := cmd.Run()
+	if ctxErr := g.ctx.Err(); runErr != nil && ctxErr != nil {
+		return TagSignature{}, fmt.Errorf("git verify-tag stopped: %w", ctxErr)
+	}
+	parseGPGStatus(status.String(), &res)
+	res.Verified = res.Verified && runErr == nil
+	return res, nil
+}
+
+func (g actualGit) HasTag(tag Tag) (bool, error) {
+	return g.check("show-ref", "--verify", "--quiet", tagRef(tag))
+}
+
+func (g actualGit) CreateTag(tag Tag, target Committish, message string, sign bool) error {
+	kind := "--annotate"
+	if sign {
+		kind = "--sign"
+	}
+	// the default cleanup would strip markdown headings as comments
+	cmd := g.command("tag", kind, "--cleanup=verbatim", "--file=-", string(tag), target.Committish())
+	cmd.Stdin = strings.NewReader(tagMessage(message))
+	if _, err := g.run(cmd); err != nil {
+		return fmt.Errorf("unable to create tag %q: %w", tag, err)
+	}
+	return nil
+}
+
+func (g actualGit) PushTag(remote string, tag Tag) error {
+	if _, err := g.output("push", remote, tagRef(tag)); err != nil {
+		return fmt.Errorf("unable to push tag %q to remote %q: %w", tag, remote, err)
+	}
+	return nil
+}
+
+// tagMessage normalizes a tag message like go-git does, so that both
+// backends create the same tags.
+func tagMessage(message string) string {
+	return strings.TrimSpace(message) + "\n"
+}
+
+// parseGPGStatus fills out the signer information from gpg's machine-readable
+// status lines.
+func parseGPGStatus(status string, sig *TagSignature) {
+	for _, line := range strings.Split(status, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "[GNUPG:]" {
+			continue
+		}
+		switch fields[1] {
+		case "GOODSIG":
+			sig.Verified = true
+			sig.Signer = strings.Join(fields[3:], " ")
+		case "VALIDSIG":
+			sig.Fingerprints = append(sig.Fingerprints, fields[2])
+			if primary := fields[len(fields)-1]; len(fields) >= 12 && primary != fields[2] {
+				sig.Fingerprints = append(sig.Fingerprints, primary)
+			}
+		}
+	}
+}
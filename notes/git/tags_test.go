@@ -0,0 +1,312 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/git"
+)
+
+var _ = Describe("Tag signatures", func() {
+	var (
+		dir                      string
+		maintainer               *openpgp.Entity
+		fingerprint, subkeyPrint string
+		publicKey                string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "notes-tags")
+		Expect(err).NotTo(HaveOccurred())
+
+		repo, err := gogit.PlainInit(dir, false)
+		Expect(err).NotTo(HaveOccurred())
+		fix := &fixture{repo: repo, clock: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+		root := fix.commit("Initial commit\n")
+		fix.ref(plumbing.NewBranchReferenceName("main"), root)
+		Expect(repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main")))).To(Succeed())
+
+		maintainer, err = openpgp.NewEntity("Some Maintainer", "", "maint@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+		Expect(err).NotTo(HaveOccurred())
+		fingerprint = strings.ToUpper(hex.EncodeToString(maintainer.PrimaryKey.Fingerprint))
+
+		fix.tag("v0.1.0", root, false)
+		fix.tag("v0.2.0", root, true)
+		_, err = repo.CreateTag("v0.3.0", root, &gogit.CreateTagOptions{
+			Tagger:  &object.Signature{Name: "Some Maintainer", Email: "maint@example.com", When: fix.clock},
+			Message: "v0.3.0",
+			SignKey: maintainer,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		// keys usually sign with a subkey, which is picked over the primary
+		// key once there is one
+		Expect(maintainer.AddSigningSubkey(&packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})).To(Succeed())
+		subkeyPrint = strings.ToUpper(hex.EncodeToString(maintainer.Subkeys[len(maintainer.Subkeys)-1].PublicKey.Fingerprint))
+		_, err = repo.CreateTag("v0.4.0", root, &gogit.CreateTagOptions{
+			Tagger:  &object.Signature{Name: "Some Maintainer", Email: "maint@example.com", When: fix.clock},
+			Message: "v0.4.0",
+			SignKey: maintainer,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var armored bytes.Buffer
+		w, err := armor.Encode(&armored, openpgp.PublicKeyType, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(maintainer.Serialize(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		publicKey = armored.String()
+	})
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	Context("with the go-git backend", func() {
+		var gitImpl *GoGit
+		BeforeEach(func() {
+			var err error
+			gitImpl, err = OpenGoGit(dir)
+			Expect(err).NotTo(HaveOccurred())
+			gitImpl = gitImpl.WithKeyring(publicKey)
+		})
+
+		It("should notice lightweight tags", func() {
+			Expect(gitImpl.VerifyTag("v0.1.0")).To(Equal(TagSignature{}))
+		})
+
+		It("should notice unsigned annotated tags", func() {
+			Expect(gitImpl.VerifyTag("v0.2.0")).To(Equal(TagSignature{Annotated: true}))
+		})
+
+		It("should verify signed tags against the keyring", func() {
+			Expect(gitImpl.VerifyTag("v0.3.0")).To(Equal(TagSignature{
+				Annotated:    true,
+				Signed:       true,
+				Verified:     true,
+				Signer:       "Some Maintainer <maint@example.com>",
+				Fingerprints: []string{fingerprint},
+			}))
+		})
+
+		It("should report the subkey that made the signature, along with its primary key", func() {
+			Expect(gitImpl.VerifyTag("v0.4.0")).To(Equal(TagSignature{
+				Annotated:    true,
+				Signed:       true,
+				Verified:     true,
+				Signer:       "Some Maintainer <maint@example.com>",
+				Fingerprints: []string{subkeyPrint, fingerprint},
+			}))
+		})
+
+		It("should not verify signed tags without a keyring", func() {
+			gitImpl = gitImpl.WithKeyring("")
+			Expect(gitImpl.VerifyTag("v0.3.0")).To(Equal(TagSignature{Annotated: true, Signed: true}))
+		})
+
+		It("should fail on tags that don't exist", func() {
+			_, err := gitImpl.VerifyTag("v9.9.9")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with the git binary", func() {
+		var (
+			actual    Repository
+			gnupgHome string
+		)
+		BeforeEach(func() {
+			for _, binary := range []string{"git", "gpg"} {
+				if _, err := exec.LookPath(binary); err != nil {
+					Skip(binary + " binary not available")
+				}
+			}
+
+			var err error
+			gnupgHome, err = os.MkdirTemp("", "notes-gnupg")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(gnupgHome, "maintainer.asc"), []byte(publicKey), 0600)).To(Succeed())
+			importKey := exec.Command("gpg", "--homedir", gnupgHome, "--batch", "--import", filepath.Join(gnupgHome, "maintainer.asc"))
+			out, err := importKey.CombinedOutput()
+			Expect(err).NotTo(HaveOccurred(), string(out))
+
+			actual = NewActual(context.Background(), dir, "GNUPGHOME="+gnupgHome)
+		})
+		AfterEach(func() {
+			Expect(os.RemoveAll(gnupgHome)).To(Succeed())
+		})
+
+		It("should notice lightweight & unsigned tags", func() {
+			Expect(actual.VerifyTag("v0.1.0")).To(Equal(TagSignature{}))
+			Expect(actual.VerifyTag("v0.2.0")).To(Equal(TagSignature{Annotated: true}))
+		})
+
+		It("should verify signed tags against gpg's keyring", func() {
+			Expect(actual.VerifyTag("v0.3.0")).To(Equal(TagSignature{
+				Annotated:    true,
+				Signed:       true,
+				Verified:     true,
+				Signer:       "Some Maintainer <maint@example.com>",
+				Fingerprints: []string{fingerprint},
+			}))
+		})
+
+		It("should report the subkey that made the signature, along with its primary key", func() {
+			Expect(actual.VerifyTag("v0.4.0")).To(Equal(TagSignature{
+				Annotated:    true,
+				Signed:       true,
+				Verified:     true,
+				Signer:       "Some Maintainer <maint@example.com>",
+				Fingerprints: []string{subkeyPrint, fingerprint},
+			}))
+		})
+
+		It("should not verify signatures from keys missing from the keyring", func() {
+			actual = NewActual(context.Background(), dir, "GNUPGHOME="+filepath.Join(gnupgHome, "empty"))
+			Expect(os.Mkdir(filepath.Join(gnupgHome, "empty"), 0700)).To(Succeed())
+			Expect(actual.VerifyTag("v0.3.0")).To(Equal(TagSignature{Annotated: true, Signed: true}))
+		})
+	})
+})
+
+var _ = Describe("Creating tags", func() {
+	var (
+		dir, remoteDir string
+		first, second  plumbing.Hash
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "notes-new-tags")
+		Expect(err).NotTo(HaveOccurred())
+		remoteDir = filepath.Join(dir, "remote.git")
+		_, err = gogit.PlainInit(remoteDir, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		repo, err := gogit.PlainInit(filepath.Join(dir, "local"), false)
+		Expect(err).NotTo(HaveOccurred())
+		fix := &fixture{repo: repo, clock: time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)}
+		first = fix.commit("Initial commit\n")
+		second = fix.commit("Second commit\n", first)
+		fix.ref(plumbing.NewBranchReferenceName("main"), second)
+		Expect(repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main")))).To(Succeed())
+
+		cfg, err := repo.Config()
+		Expect(err).NotTo(HaveOccurred())
+		cfg.User.Name = "Some Maintainer"
+		cfg.User.Email = "maint@example.com"
+		cfg.Remotes["origin"] = &config.RemoteConfig{Name: "origin", URLs: []string{remoteDir}}
+		Expect(repo.SetConfig(cfg)).To(Succeed())
+	})
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	backends := []struct {
+		name string
+		open func(dir string) Repository
+	}{
+		{name: "the go-git backend", open: func(dir string) Repository {
+			gitImpl, err := OpenGoGit(dir)
+			Expect(err).NotTo(HaveOccurred())
+			return gitImpl
+		}},
+		{name: "the git binary", open: func(dir string) Repository {
+			if _, err := exec.LookPath("git"); err != nil {
+				Skip("git binary not available")
+			}
+			return NewActual(context.Background(), dir)
+		}},
+	}
+	for _, backend := range backends {
+		backend := backend
+		Context("with "+backend.name, func() {
+			var gitImpl Repository
+			BeforeEach(func() {
+				gitImpl = backend.open(filepath.Join(dir, "local"))
+			})
+
+			It("should create annotated tags with the message kept verbatim", func() {
+				Expect(gitImpl.HasTag("v0.2.0")).To(BeFalse())
+				Expect(gitImpl.CreateTag("v0.2.0", SomeCommittish("main"), "# v0.2.0\n\n## :bug: Bug Fixes\n\n- Fix a thing (#2)\n", false)).To(Succeed())
+				Expect(gitImpl.HasTag("v0.2.0")).To(BeTrue())
+
+				repo, err := gogit.PlainOpen(filepath.Join(dir, "local"))
+				Expect(err).NotTo(HaveOccurred())
+				ref, err := repo.Tag("v0.2.0")
+				Expect(err).NotTo(HaveOccurred())
+				tag, err := repo.TagObject(ref.Hash())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tag.Target).To(Equal(second))
+				Expect(tag.Tagger.Name).To(Equal("Some Maintainer"))
+				Expect(tag.Message).To(Equal("# v0.2.0\n\n## :bug: Bug Fixes\n\n- Fix a thing (#2)\n"))
+				Expect(gitImpl.VerifyTag("v0.2.0")).To(Equal(TagSignature{Annotated: true}))
+			})
+
+			It("should refuse to overwrite existing tags", func() {
+				Expect(gitImpl.CreateTag("v0.2.0", SomeCommittish("main"), "v0.2.0", false)).To(Succeed())
+				Expect(gitImpl.CreateTag("v0.2.0", Commit(first.String()), "v0.2.0", false)).NotTo(Succeed())
+			})
+
+			It("should push just the given tag", func() {
+				Expect(gitImpl.CreateTag("v0.1.0", Commit(first.String()), "v0.1.0", false)).To(Succeed())
+				Expect(gitImpl.CreateTag("v0.2.0", SomeCommittish("main"), "v0.2.0", false)).To(Succeed())
+				Expect(gitImpl.PushTag("origin", "v0.2.0")).To(Succeed())
+
+				remote, err := gogit.PlainOpen(remoteDir)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = remote.Tag("v0.2.0")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = remote.Tag("v0.1.0")
+				Expect(err).To(MatchError(gogit.ErrTagNotFound))
+			})
+
+			It("should check whether commits are ancestors of others", func() {
+				Expect(gitImpl.IsAncestor(Commit(first.String()), SomeCommittish("main"))).To(BeTrue())
+				Expect(gitImpl.IsAncestor(SomeCommittish("main"), SomeCommittish("main"))).To(BeTrue())
+				Expect(gitImpl.IsAncestor(SomeCommittish("main"), Commit(first.String()))).To(BeFalse())
+
+				_, err := gitImpl.IsAncestor(SomeCommittish("nonexistent"), SomeCommittish("main"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	}
+
+	It("should refuse to sign tags without the git binary", func() {
+		gitImpl, err := OpenGoGit(filepath.Join(dir, "local"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gitImpl.CreateTag("v0.2.0", SomeCommittish("main"), "v0.2.0", true)).NotTo(Succeed())
+		Expect(gitImpl.HasTag("v0.2.0")).To(BeFalse())
+	})
+})
@@ -17,8 +17,12 @@ limitations under the License.
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
 
 	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
@@ -53,67 +57,242 @@ func (c SomeCommittish) Committish() string {
 // Git runs the git-related functionality used by the release notes package,
 // (that way a mock can be produced).
 type Git interface {
-	// ClosestTag finds the closest tag to the given committish.
-	ClosestTag(initial Committish) (Tag, error)
+	// VersionTags lists all the tags named like PREFIXvX... (for modules that
+	// are tagged separately from the rest of the repository, like
+	// `tools/setup-envtest/v0.1.0`), whether or not they're reachable from
+	// any particular branch, sorted by name.
+	VersionTags(prefix string) ([]Tag, error)
+	// MergedTags lists all the tags (version or not) that point at commits
+	// reachable from the given committish, like `git tag --merged`, sorted
+	// by name.
+	MergedTags(into Committish) ([]Tag, error)
 	// FirstCommit finds the first commit on a given branch.
 	FirstCommit(branchName string) (Commit, error)
 	// HasUpstream checks if a given branch has an upstream, returning an error if it does not.
 	HasUpstream(branchName string) error
-	// MergeCommitsBetween shows all the merge commits between start and end,
-	// in %B (raw body) form.
-	MergeCommitsBetween(start, end Committish) (string, error)
+	// MergeCommitsBetween lists all the merge commits between start and end,
+	// most recent first.
+	MergeCommitsBetween(start, end Committish) ([]CommitRecord, error)
+	// FirstParentCommitsBetween lists all the commits between start and end
+	// that are on the first-parent history of end (i.e. the commits actually
+	// made on that branch, merge or not), most recent first.
+	FirstParentCommitsBetween(start, end Committish) ([]CommitRecord, error)
+	// VerifyTag checks whether the given tag is annotated & signed, and
+	// verifies the signature, if any.  Signatures that can't be verified
+	// (e.g. because the key is unknown) aren't errors, they're just not
+	// Verified.
+	VerifyTag(tag Tag) (TagSignature, error)
+	// ChangedFiles lists the paths of the files changed by the given commit
+	// compared to its first parent (so for merge commits, the files changed
+	// by whatever was merged), sorted.
+	ChangedFiles(commit Commit) ([]string, error)
+	// PatchID identifies the changes made by the given commit compared to its
+	// first parent (see ChangedFiles), such that cherry-picks of the same
+	// change get the same ID, like `git patch-id` (see patchID for the
+	// details).  Commits that don't change the contents of any files have an
+	// empty ID.
+	PatchID(commit Commit) (string, error)
 }
 
-// Actual calls out to the git command to get results.
-var Actual = actualGit{}
+// Repository is a Git that also supports the rest of the repository
+// operations used by the notes command.
+type Repository interface {
+	Git
+	// CurrentBranch returns the name of the currently checked-out branch.
+	CurrentBranch() (string, error)
+	// RemoteForUpstreamFor returns the remote for the upstream for the given branch.
+	RemoteForUpstreamFor(branchName string) (string, error)
+	// URLForRemote returns the fetch URL for the given remote.
+	URLForRemote(remote string) (string, error)
+	// Fetch fetches the given remote (including tags).
+	Fetch(remote string) error
+
+	// IsAncestor checks whether ancestor is reachable from descendant (a
+	// commit is its own ancestor).
+	IsAncestor(ancestor, descendant Committish) (bool, error)
+	// HasTag checks whether the given tag exists.
+	HasTag(tag Tag) (bool, error)
+	// CreateTag creates an annotated tag pointing at target with the given
+	// message (kept verbatim), signing it with the user's key if sign is
+	// set.
+	CreateTag(tag Tag, target Committish, message string, sign bool) error
+	// PushTag pushes just the given tag to the given remote.
+	PushTag(remote string, tag Tag) error
+}
+
+// Actual calls out to the git command in the current directory to get
+// results.
+var Actual = actualGit{ctx: context.Background()}
+
+var (
+	_ Repository = Actual
+	_ Repository = &GoGit{}
+)
+
+// NewActual calls out to the git command to get results for the repository
+// containing dir (or the current directory, if empty).  Calls stop when ctx is
+// cancelled.  Any env variables (in KEY=value form, like GIT_DIR=...) are
+// passed to git, overriding the process's environment and DefaultEnv.
+func NewActual(ctx context.Context, dir string, env ...string) Repository {
+	return actualGit{ctx: ctx, dir: dir, env: env}
+}
+
+// DefaultEnv is passed to every git call, so that the output is consistent
+// no matter how the user has things set up, and so that git never waits for
+// input.
+var DefaultEnv = []string{
+	"GIT_PAGER=cat",
+	"PAGER=cat",
+	"LC_ALL=C",
+	"GIT_TERMINAL_PROMPT=0",
+}
 
 // actualGit calls out to the git command to get results.
-type actualGit struct{}
+type actualGit struct {
+	ctx context.Context
+	dir string
+	env []string
+}
+
+// command prepares a git command in the configured directory & environment.
+func (g actualGit) command(args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(g.ctx, "git", args...)
+	cmd.Dir = g.dir
+	cmd.Env = append(append(os.Environ(), DefaultEnv...), g.env...)
+	return cmd
+}
+
+// output runs a git command, returning its output.  If the context was
+// cancelled, that's returned as the error (instead of just "killed").
+func (g actualGit) output(args ...string) (string, error) {
+	return g.run(g.command(args...))
+}
 
-func (actualGit) ClosestTag(initial Committish) (Tag, error) {
-	latestTagCmd := exec.Command("git", "describe", "--tags", "--abbrev=0", initial.Committish())
-	tagRaw, err := latestTagCmd.Output()
+// run runs a prepared git command (e.g. one with some input), returning its
+// output like output does.
+func (g actualGit) run(cmd *exec.Cmd) (string, error) {
+	out, err := cmd.Output()
+	if ctxErr := g.ctx.Err(); err != nil && ctxErr != nil {
+		return "", fmt.Errorf("git %s stopped: %w", cmd.Args[1], ctxErr)
+	}
+	return string(out), common.ErrOut(err)
+}
+
+// check runs a git command that answers a yes-or-no question with its exit
+// code (zero for yes, one for no).  Other failures are errors.
+func (g actualGit) check(args ...string) (bool, error) {
+	_, err := g.command(args...).Output()
+	if ctxErr := g.ctx.Err(); err != nil && ctxErr != nil {
+		return false, fmt.Errorf("git %s stopped: %w", args[0], ctxErr)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
 	if err != nil {
-		return Tag(""), common.ErrOut(err)
+		return false, common.ErrOut(err)
 	}
+	return true, nil
+}
 
-	return Tag(strings.TrimSpace(string(tagRaw))), nil
+func (g actualGit) VersionTags(prefix string) ([]Tag, error) {
+	out, err := g.output("tag", "--list", versionTagPattern(prefix))
+	if err != nil {
+		return nil, err
+	}
+	return tagLines(out), nil
 }
-func (actualGit) FirstCommit(branchName string) (Commit, error) {
-	cmd := exec.Command("git", "rev-list", "--max-parents=0", branchName)
-	out, err := cmd.Output()
+
+func (g actualGit) MergedTags(into Committish) ([]Tag, error) {
+	out, err := g.output("tag", "--list", "--merged", into.Committish())
 	if err != nil {
-		return "", common.ErrOut(err)
+		return nil, err
 	}
-	return Commit(strings.TrimSpace(string(out))), nil
+	return tagLines(out), nil
 }
-func (actualGit) HasUpstream(branchName string) error {
-	return exec.Command("git", "rev-parse", "--abbrev=0", "--symbolic-full-name", branchName).Run()
+
+// tagLines splits the output of `git tag --list` into tags.
+func tagLines(out string) []Tag {
+	var tags []Tag
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			tags = append(tags, Tag(line))
+		}
+	}
+	return tags
+}
+
+// versionTagPattern is the glob for version tags with the given prefix.
+func versionTagPattern(prefix string) string {
+	return prefix + "v[0-9]*"
 }
-func (actualGit) CurrentBranch() (string, error) {
-	currentBranchName, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+
+func (g actualGit) FirstCommit(branchName string) (Commit, error) {
+	out, err := g.output("rev-list", "--max-parents=0", branchName)
 	if err != nil {
-		return "", fmt.Errorf("unable to determine current branch from HEAD: %w", common.ErrOut(err))
+		return "", err
 	}
-	return strings.TrimSpace(string(currentBranchName)), err
+	return Commit(strings.TrimSpace(out)), nil
+}
+func (g actualGit) HasUpstream(branchName string) error {
+	_, err := g.output("rev-parse", "--abbrev=0", "--symbolic-full-name", branchName)
+	return err
+}
+func (g actualGit) CurrentBranch() (string, error) {
+	currentBranchName, err := g.output("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("unable to determine current branch from HEAD: %w", err)
+	}
+	return strings.TrimSpace(currentBranchName), nil
+}
+func (g actualGit) MergeCommitsBetween(start, end Committish) ([]CommitRecord, error) {
+	return g.logBetween(start, end, "--merges")
+}
+func (g actualGit) FirstParentCommitsBetween(start, end Committish) ([]CommitRecord, error) {
+	return g.logBetween(start, end, "--first-parent")
 }
-func (actualGit) MergeCommitsBetween(start, end Committish) (string, error) {
-	listCommits := exec.Command("git", "rev-list", start.Committish()+".."+end.Committish(), "--merges", "--pretty=format:%B")
 
-	commitsRaw, err := listCommits.Output()
+// logBetween lists the commits between start and end with `git log`,
+// filtered by the given extra flags.
+func (g actualGit) logBetween(start, end Committish, flags ...string) ([]CommitRecord, error) {
+	args := append([]string{"log", "-z", logFormat}, flags...)
+	commitsRaw, err := g.output(append(args, start.Committish()+".."+end.Committish())...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(commitsRaw)
+}
+
+func (g actualGit) ChangedFiles(commit Commit) ([]string, error) {
+	filesRaw, err := g.output("diff-tree", "-r", "-z", "--name-only", "--no-commit-id", "--root", "--diff-merges=first-parent", commit.Committish())
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, file := range strings.Split(filesRaw, "\x00") {
+		if file != "" {
+			res = append(res, file)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (g actualGit) PatchID(commit Commit) (string, error) {
+	patch, err := g.output("diff-tree", "-p", "--no-color", "--no-ext-diff", "--no-renames", "--no-commit-id", "--root", "--diff-merges=first-parent", commit.Committish())
 	if err != nil {
 		return "", err
 	}
-	return string(commitsRaw), nil
+	return patchID(patch), nil
 }
 
 // RemoteForUpstreamFor returns the remote for the upstream for the given branch.
-func (actualGit) RemoteForUpstreamFor(branchName string) (string, error) {
-	remoteForBranch, err := exec.Command("git", "for-each-ref", "--format=%(upstream:remotename)", "refs/heads/"+branchName).Output()
+func (g actualGit) RemoteForUpstreamFor(branchName string) (string, error) {
+	remoteForBranch, err := g.output("for-each-ref", "--format=%(upstream:remotename)", "refs/heads/"+branchName)
 	if err != nil {
-		return "", common.ErrOut(err)
+		return "", err
 	}
-	res := strings.TrimSpace(string(remoteForBranch))
+	res := strings.TrimSpace(remoteForBranch)
 	if res == "" {
 		return "", fmt.Errorf("no upstream/remote found")
 	}
@@ -121,15 +300,21 @@ func (actualGit) RemoteForUpstreamFor(branchName string) (string, error) {
 }
 
 // URLForRemote returns the fetch URL for the given remote.
-func (actualGit) URLForRemote(remote string) (string, error) {
-	upstreamURLRaw, err := exec.Command("git", "remote", "get-url", remote).Output()
+func (g actualGit) URLForRemote(remote string) (string, error) {
+	upstreamURLRaw, err := g.output("remote", "get-url", remote)
 	if err != nil {
-		return "", common.ErrOut(err)
+		return "", err
 	}
-	return strings.TrimSpace(string(upstreamURLRaw)), nil
+	return strings.TrimSpace(upstreamURLRaw), nil
 }
 
 // Fetch fetches the given remote (including tags)
-func (actualGit) Fetch(remote string) error {
-	return common.ErrOut(exec.Command("git", "fetch", "--tags", remote).Run())
+func (g actualGit) Fetch(remote string) error {
+	_, err := g.output("fetch", "--tags", remote)
+	return err
+}
+
+// IsAncestor checks whether ancestor is reachable from descendant.
+func (g actualGit) IsAncestor(ancestor, descendant Committish) (bool, error) {
+	return g.check("merge-base", "--is-ancestor", ancestor.Committish(), descendant.Committish())
 }
@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pulls_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/kubebuilder-release-tools/notes/pulls"
+)
+
+var _ = Describe("Fetching PRs from GitHub", func() {
+	var (
+		server   *httptest.Server
+		lastAuth string
+		requests int
+	)
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/repos/kubernetes-sigs/controller-runtime/pulls/1163", func(w http.ResponseWriter, r *http.Request) {
+			lastAuth = r.Header.Get("Authorization")
+			requests++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{
+				"number": 1163,
+				"title": "Controller.Watch() should not store watches if already started",
+				"body": "Fixes #1162",
+				"labels": [{"name": "kind/bug"}, {"name": "lgtm"}]
+			}`))
+		})
+		mux.HandleFunc("/repos/kubernetes-sigs/controller-runtime/commits/1e2f8a3c/pulls", func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{
+				"number": 1170,
+				"title": "WIP: build on the webhook changes",
+				"state": "open"
+			}, {
+				"number": 1165,
+				"title": "Refactor the webhook server",
+				"body": "Rebased onto main",
+				"state": "closed",
+				"merged_at": "2020-10-02T12:00:00Z",
+				"labels": [{"name": "kind/cleanup"}]
+			}]`))
+		})
+		mux.HandleFunc("/repos/kubernetes-sigs/controller-runtime/commits/9b7c4d01/pulls", func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[]`))
+		})
+		server = httptest.NewServer(mux)
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should fetch the labels and body for a PR", func() {
+		source, err := NewGitHub(context.Background(), "kubernetes-sigs/controller-runtime", "", server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(source.PullRequest("1163")).To(Equal(Info{
+			Number: "1163",
+			Labels: []string{"kind/bug", "lgtm"},
+			Body:   "Fixes #1162",
+		}))
+	})
+
+	It("should only fetch each PR once when cached", func() {
+		source, err := NewGitHub(context.Background(), "kubernetes-sigs/controller-runtime", "", server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		cached := Cached(source)
+
+		requests = 0
+		first, err := cached.PullRequest("1163")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached.PullRequest("1163")).To(Equal(first))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("should find the merged PR that a commit came from", func() {
+		source, err := NewGitHub(context.Background(), "kubernetes-sigs/controller-runtime", "", server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		info, found, err := source.PullRequestFor("1e2f8a3c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(info).To(Equal(Info{
+			Number: "1165",
+			Labels: []string{"kind/cleanup"},
+			Body:   "Rebased onto main",
+		}))
+	})
+
+	It("should report commits that didn't come from a merged PR", func() {
+		source, err := NewGitHub(context.Background(), "kubernetes-sigs/controller-runtime", "", server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, found, err := source.PullRequestFor("9b7c4d01")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("should only look up each commit once when cached", func() {
+		source, err := NewGitHub(context.Background(), "kubernetes-sigs/controller-runtime", "", server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		cached := Cached(source)
+
+		requests = 0
+		for i := 0; i < 2; i++ {
+			_, found, err := cached.PullRequestFor("1e2f8a3c")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			_, found, err = cached.PullRequestFor("9b7c4d01")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		}
+		Expect(requests).To(Equal(2))
+
+		// the PR itself is remembered too
+		Expect(cached.PullRequest("1165")).To(HaveField("Number", "1165"))
+		Expect(requests).To(Equal(2))
+	})
+
+	It("should authenticate with the token if one was given", func() {
+		source, err := NewGitHub(context.Background(), "kubernetes-sigs/controller-runtime", "s3cr3t", server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = source.PullRequest("1163")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lastAuth).To(Equal("Bearer s3cr3t"))
+	})
+
+	It("should fail on PRs that don't exist", func() {
+		source, err := NewGitHub(context.Background(), "kubernetes-sigs/controller-runtime", "", server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = source.PullRequest("1164")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should stop fetching once the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		source, err := NewGitHub(ctx, "kubernetes-sigs/controller-runtime", "", server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		cancel()
+
+		requests = 0
+		_, err = source.PullRequest("1163")
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(requests).To(Equal(0))
+	})
+
+	It("should reject projects not of the form org/repo", func() {
+		_, err := NewGitHub(context.Background(), "controller-runtime", "", server.URL)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pulls looks up information about merged PRs that isn't available
+// from git history alone.
+package pulls
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"golang.org/x/oauth2"
+)
+
+// Info is the information about a PR that's not in git history.
+type Info struct {
+	// Number is the PR number.
+	Number string
+	// Labels are the names of the labels on the PR.
+	Labels []string
+	// Body is the PR description.
+	Body string
+}
+
+// Source fetches information about PRs (that way a fake can be produced).
+type Source interface {
+	// PullRequest fetches the information for the PR with the given number.
+	PullRequest(number string) (Info, error)
+	// PullRequestFor finds the merged PR that the commit with the given SHA
+	// came from, returning false if it didn't come from one.
+	PullRequestFor(commit string) (Info, bool, error)
+}
+
+// GitHub fetches PR information from the GitHub API.
+type GitHub struct {
+	ctx    context.Context
+	client *github.Client
+	owner  string
+	repo   string
+}
+
+// NewGitHub creates a Source that fetches PRs in the given project (org/repo)
+// from the GitHub API.  Requests stop when ctx is cancelled.  If token is set,
+// it's used to authenticate.  If baseURL is set, it's used instead of the
+// public GitHub API (e.g. for GitHub Enterprise, or for tests).
+func NewGitHub(ctx context.Context, project, token, baseURL string) (*GitHub, error) {
+	parts := strings.Split(project, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("project %q is not of the form org/repo", project)
+	}
+
+	var httpClient *http.Client
+	if token != "" {
+		httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		))
+	}
+	client := github.NewClient(httpClient)
+
+	if baseURL != "" {
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		parsedURL, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL %q: %w", baseURL, err)
+		}
+		client.BaseURL = parsedURL
+	}
+
+	return &GitHub{
+		ctx:    ctx,
+		client: client,
+		owner:  parts[0],
+		repo:   parts[1],
+	}, nil
+}
+
+// PullRequest implements Source.
+func (g *GitHub) PullRequest(number string) (Info, error) {
+	num, err := strconv.Atoi(number)
+	if err != nil {
+		return Info{}, fmt.Errorf("invalid PR number %q: %w", number, err)
+	}
+
+	pr, _, err := g.client.PullRequests.Get(g.ctx, g.owner, g.repo, num)
+	if err != nil {
+		return Info{}, fmt.Errorf("unable to fetch PR #%s from %s/%s: %w", number, g.owner, g.repo, err)
+	}
+
+	return infoFrom(pr), nil
+}
+
+// PullRequestFor implements Source.  GitHub associates commits with the PRs
+// they were merged by, whether as merge commits, squash merges, or the
+// individual commits of rebase merges.
+func (g *GitHub) PullRequestFor(commit string) (Info, bool, error) {
+	prs, _, err := g.client.PullRequests.ListPullRequestsWithCommit(g.ctx, g.owner, g.repo, commit, nil)
+	if err != nil {
+		return Info{}, false, fmt.Errorf("unable to fetch the PRs for commit %s from %s/%s: %w", commit, g.owner, g.repo, err)
+	}
+	for _, pr := range prs {
+		// open PRs (and closed, unmerged ones) may contain the commit too,
+		// if they were based on a branch that has it
+		if pr.MergedAt != nil {
+			return infoFrom(pr), true, nil
+		}
+	}
+	return Info{}, false, nil
+}
+
+// infoFrom extracts the information we care about from a GitHub PR.
+func infoFrom(pr *github.PullRequest) Info {
+	info := Info{Number: strconv.Itoa(pr.GetNumber()), Body: pr.GetBody()}
+	for _, label := range pr.Labels {
+		info.Labels = append(info.Labels, label.GetName())
+	}
+	return info
+}
+
+// cached is a Source that remembers PRs that it's already fetched.
+type cached struct {
+	source Source
+	prs    map[string]Info
+	// commits holds the PRs that commits came from (nil for commits that
+	// didn't come from one), by SHA.
+	commits map[string]*Info
+}
+
+// Cached wraps the given Source so that each PR is only fetched once, since
+// several passes over a changelog may need information about the same PR.
+func Cached(source Source) Source {
+	return &cached{source: source, prs: make(map[string]Info), commits: make(map[string]*Info)}
+}
+
+// PullRequest implements Source.
+func (c *cached) PullRequest(number string) (Info, error) {
+	if info, seen := c.prs[number]; seen {
+		return info, nil
+	}
+	info, err := c.source.PullRequest(number)
+	if err != nil {
+		return Info{}, err
+	}
+	c.prs[number] = info
+	return info, nil
+}
+
+// PullRequestFor implements Source.
+func (c *cached) PullRequestFor(commit string) (Info, bool, error) {
+	if info, seen := c.commits[commit]; seen {
+		if info == nil {
+			return Info{}, false, nil
+		}
+		return *info, true, nil
+	}
+	info, found, err := c.source.PullRequestFor(commit)
+	if err != nil {
+		return Info{}, false, err
+	}
+	if !found {
+		c.commits[commit] = nil
+		return Info{}, false, nil
+	}
+	c.commits[commit] = &info
+	c.prs[info.Number] = info
+	return info, true, nil
+}
@@ -23,44 +23,182 @@ Use these as the base of your release notes.
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"sort"
 	"strings"
 
+	"github.com/blang/semver/v4"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/common"
 	"sigs.k8s.io/kubebuilder-release-tools/notes/compose"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/forge"
 	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/pulls"
 )
 
 var (
 	fromTag          = flag.String("from", "", "The tag or commit to start from.")
 	branchName       = flag.String("branch", "", "The release branch to run on (defaults to current)")
-	showOthers       = flag.String("show-others", "", "Comma-separate set of non-code changes to show (docs,infra)")
-	project          = flag.String("project", "", "GitHub project in org/repo form to use to generate link to past releases (defaults to a value extracted from the remote of the branch or 'upstream'")
+	showOthers       = flag.String("show-others", "", "Comma-separate set of optional changes to show (docs,infra,release, or any optional custom PR type)")
+	project          = flag.String("project", "", "project to use to generate links to past releases & PRs, either in org/repo form (for github.com), or as a URL (defaults to a value extracted from the remote of the branch or 'upstream')")
+	forgeKind        = flag.String("forge", "", "kind of server hosting the project, for links -- github, gitlab, or gitea (defaults to a guess based on the host name, falling back to github)")
 	useUpstreams     = flag.Bool("use-upstream", true, "try to compose information from upstream versions of the local release branches")
 	refreshUpstreams = flag.Bool("refresh-upstream", true, "git-fetch the remote for the current branch before continuing (only relevant if use-upstream is set)")
 	relType          = flag.String("r", "final", "type of release -- final, alpha, beta, or rc")
 	forceV1          = flag.Bool("force-v1", false, "if the current release is 0.Y-style, assume the next 'major' release is 1.0 instead of being 0.Y-style")
 	extraInfoOnFinal = flag.Bool("print-full-final", true, "if the current release would bring us from pre-release to final, print the full changes since the last final release")
+	prTypesFile      = flag.String("types", "", "YAML or JSON file with custom PR types and section headings to use in addition to (or to override) the built-in ones")
+	groupByArea      = flag.Bool("group-by-area", false, "group the changes in each section by the [area] (or Conventional Commits scope) from their titles")
+	titleConvention  = flag.String("convention", "", "how PR types are marked in titles -- emoji, conventional (Conventional Commits), or any (defaults to emoji, or the convention from --types)")
+	useLabels        = flag.Bool("use-labels", false, "look up the GitHub labels of PRs without a recognized title prefix to categorize them (uses $GITHUB_TOKEN, if set)")
+	useReleaseNotes  = flag.Bool("use-release-notes", false, "look up the bodies of PRs on GitHub, and use the contents of their ```release-note blocks instead of the titles (uses $GITHUB_TOKEN, if set)")
+	promoteBreaking  = flag.Bool("promote-breaking", false, "look up the bodies of PRs on GitHub, and treat PRs with explicit breaking-change markers (BREAKING CHANGE:, ACTION REQUIRED:) as breaking (uses $GITHUB_TOKEN, if set)")
+	renderStyle      = flag.String("style", string(common.ShortcodeStyle), "how to display PR types in section headings -- shortcode (GitHub markdown), unicode (emoji), or text (plain labels)")
+	paths            = flag.String("paths", "", "comma-separated paths (relative to the root of the repository) to limit the notes to, listing only PRs that change something under them (e.g. for a module in a subdirectory)")
+	tagPrefix        = flag.String("tag-prefix", "", "prefix of the release tags, for modules that are tagged separately from the rest of the repository (e.g. tools/setup-envtest/ for tags like tools/setup-envtest/v0.1.0)")
+	branchScheme     = flag.String("branch-scheme", "kubebuilder", "how release branches are named -- kubebuilder (release-X, or release-0.Y for 0.Y releases), or a pattern with {major} and {minor} placeholders for one branch per minor release (e.g. release-{major}.{minor}, release/{major}.x)")
+	devBranches      = flag.String("development-branches", "main,master", "comma-separated names of development branches, on which the notes preview the next minor or major release, starting from the latest release overall")
+	mergeStrategy    = flag.String("merge-strategy", string(compose.MergeStrategyMerge), "how PRs get merged into the branch -- merge (merge commits), squash (squash merges, titled \"... (#N)\"), rebase (like squash, but grouping runs of commits from the same PR, which are looked up on GitHub if their titles don't say), or auto (any of those)")
+	shippedChanges   = flag.String("shipped", string(compose.ShippedKeep), "what to do with changes that were already released from an earlier release line, like fixes cherry-picked into the previous release branch's patch releases -- keep, annotate (with the release that shipped them), or drop (recognizes \"cherry picked from commit\" lines & identical changes, as well as cherry-pick PR descriptions if PRs are looked up on GitHub)")
+	tagCheck         = flag.String("tag-check", "warn", "what to do when the previous release tag isn't annotated & signed by an allowed key -- warn, strict (fail), or off")
+	tagKeys          = flag.String("tag-keys", "", "comma-separated fingerprints (or long key IDs) of the keys allowed to sign release tags (defaults to any key in the keyring)")
+	tagKeyring       = flag.String("tag-keyring", "", "file with armored PGP public keys to verify release tags against, for --git-backend go (the exec backend uses gpg's keyring)")
+	repoDir          = flag.String("repo", ".", "path to (somewhere inside) the repository to generate notes for")
+	gitTimeout       = flag.Duration("git-timeout", 0, "give up on git operations (like refreshing upstreams) and GitHub lookups that take longer than this overall (defaults to no timeout)")
+	gitBackend       = flag.String("git-backend", "exec", "how to read the git repository -- exec (call the git binary) or go (built-in, for environments without git)")
+	githubURL        = flag.String("github-api-url", "", "base URL of the GitHub API to use with --use-labels, --use-release-notes, --promote-breaking, and the rebase & auto merge strategies (defaults to the public GitHub API)")
+	releaseVersion   = flag.String("version", "", "the version being released, e.g. v0.7.0 (defaults to the version computed from the changes)")
+	initialVersion   = flag.String("initial-version", "", "the version of the first release, for branches that have no releases yet, e.g. v1.0.0 (defaults to v0.1.0); must belong on the branch, and -r makes pre-releases of it")
+	signTag          = flag.Bool("sign", false, "(tag mode) sign the release tag with your default key, like `git tag -s` (needs --git-backend exec)")
+	pushTag          = flag.Bool("push", false, "(tag mode) push the release tag (and nothing else) to the branch's remote (or 'upstream') once it's created")
+	assumeYes        = flag.Bool("yes", false, "(tag mode) create the release tag without asking for confirmation first")
+	outputFormat     = flag.String("output", "markdown", "how to print the notes -- markdown, or json (the version, why it was chosen, and the changes, for other tools to consume)")
 )
 
+// tagMode is set when running as `notes tag`, which tags the release with
+// the notes instead of just printing them.
+var tagMode bool
+
+// out is where the notes get printed.
+var out io.Writer = os.Stdout
+
+// repo is the git repository to read, according to --git-backend.
+var repo git.Repository = git.Actual
+
+// projectInfo is the project that links point to, according to --project,
+// --forge, and the upstream remote.
+var projectInfo = forge.Project{Kind: forge.GitHub}
+
+// prInfo fetches information about PRs from GitHub, if --use-labels,
+// --use-release-notes, or --promote-breaking is set, or the merge strategy is
+// rebase or auto.
+var prInfo pulls.Source
+
 // run wraps what would otherwise be main to have one error handler with
 // detailed stderr on exec errors
 func run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *gitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *gitTimeout)
+		defer cancel()
+	}
+
+	if *prTypesFile != "" {
+		taxonomy, err := common.LoadTaxonomy(*prTypesFile)
+		if err != nil {
+			return err
+		}
+		common.SetTaxonomy(taxonomy)
+	}
+	switch *gitBackend {
+	case "exec":
+		repo = git.NewActual(ctx, *repoDir)
+	case "go":
+		goGit, err := git.OpenGoGit(*repoDir)
+		if err != nil {
+			return err
+		}
+		if *tagKeyring != "" {
+			keys, err := os.ReadFile(*tagKeyring)
+			if err != nil {
+				return fmt.Errorf("unable to read tag keyring: %w", err)
+			}
+			goGit = goGit.WithKeyring(string(keys))
+		}
+		repo = goGit.WithContext(ctx)
+	default:
+		return fmt.Errorf("unknown git backend %q, must be exec|go", *gitBackend)
+	}
+
+	switch *tagCheck {
+	case "warn", "strict", "off":
+	default:
+		return fmt.Errorf("unknown tag check mode %q, must be warn|strict|off", *tagCheck)
+	}
+	switch *outputFormat {
+	case "markdown":
+	case "json":
+		if tagMode {
+			return fmt.Errorf("--output json can't be used in tag mode, since the tag message is the markdown notes")
+		}
+	default:
+		return fmt.Errorf("unknown output format %q, must be markdown|json", *outputFormat)
+	}
+	if err := common.RenderStyle(*renderStyle).Validate(); err != nil {
+		return err
+	}
+	if *titleConvention != "" {
+		if err := common.CurrentTaxonomy().SetConvention(common.TitleConvention(*titleConvention)); err != nil {
+			return err
+		}
+	}
+
 	if *fromTag == "" {
 		var err error
-		*branchName, err = git.Actual.CurrentBranch()
+		*branchName, err = repo.CurrentBranch()
 		if err != nil {
 			return err
 		}
 	}
 	log.Printf("starting from branch %q", *branchName)
 
-	branch, err := compose.ReleaseFromBranch(*branchName)
+	scheme, err := compose.ParseBranchScheme(*branchScheme)
 	if err != nil {
 		return err
 	}
+	branch, err := compose.ParseReleaseBranch(scheme, *branchName)
+	if err != nil {
+		if !isDevelopmentBranch(*branchName) {
+			return err
+		}
+		log.Printf("branch %q is a development branch, previewing the next minor or major release", *branchName)
+		branch = compose.DevelopmentBranch(*branchName)
+	}
+	branch.MergeStrategy = compose.MergeStrategy(*mergeStrategy)
+	if err := branch.MergeStrategy.Validate(); err != nil {
+		return err
+	}
+	branch.Shipped = compose.ShippedPolicy(*shippedChanges)
+	if err := branch.Shipped.Validate(); err != nil {
+		return err
+	}
+	for _, path := range strings.Split(*paths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			branch.Paths = append(branch.Paths, path)
+		}
+	}
+	branch.TagPrefix = *tagPrefix
 
 	if *useUpstreams {
 		branch.UseUpstream = true
@@ -72,40 +210,96 @@ func run() error {
 		}
 	}
 
+	if *project == "" {
+		var (
+			found forge.Project
+			err   error
+		)
+		if branch.UseUpstream {
+			// use the local branch so we don't try to get the remote for an upstream itself
+			found, err = findProject(branch.Local().String())
+		} else {
+			log.Printf("current branch %q has no assicated upstream, assuming upstream remote is \"upstream\" for auto-setting project", branch)
+			found, err = findProject("")
+		}
+		if err != nil {
+			log.Printf("unable to determine URL for upstream remote (set --project manually): %v", err)
+		} else {
+			projectInfo = found
+		}
+	} else {
+		projectInfo, err = forge.ParseProject(*project)
+		if err != nil {
+			return err
+		}
+	}
+	if *forgeKind != "" {
+		projectInfo.Kind = forge.Kind(*forgeKind)
+		if err := projectInfo.Kind.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// GitHub's rebase merges don't record the PR in commit titles, so we
+	// have to ask which PR each commit came from
+	lookUpCommits := branch.MergeStrategy == compose.MergeStrategyRebase || branch.MergeStrategy == compose.MergeStrategyAuto
+	if *useLabels || *useReleaseNotes || *promoteBreaking {
+		if projectInfo.Kind != forge.GitHub {
+			return fmt.Errorf("--use-labels, --use-release-notes, and --promote-breaking are only supported for GitHub projects, not %s", projectInfo.Kind)
+		}
+	} else if lookUpCommits && projectInfo.Kind != forge.GitHub {
+		log.Printf("can't look up the PRs that commits came from for %s projects, so only commits with a PR number in their title will be listed", projectInfo.Kind)
+		lookUpCommits = false
+	}
+	if *useLabels || *useReleaseNotes || *promoteBreaking || lookUpCommits {
+		apiURL := *githubURL
+		if apiURL == "" {
+			apiURL = projectInfo.GitHubAPIURL()
+		}
+		source, err := pulls.NewGitHub(ctx, projectInfo.Path(), os.Getenv("GITHUB_TOKEN"), apiURL)
+		if err != nil {
+			return err
+		}
+		prInfo = pulls.Cached(source)
+	}
+	branch.ShippedPRs = prInfo
+	branch.CommitPRs = prInfo
+
 	var (
 		changes compose.ChangeLog
 		since   git.Committish
 	)
 	if *fromTag == "" {
-		changes, since, err = compose.Changes(git.Actual, &branch)
+		changes, since, err = compose.Changes(repo, &branch)
 	} else {
 		since = git.SomeCommittish(*fromTag)
-		changes, err = compose.ChangesSince(git.Actual, branch, since)
+		changes, err = compose.ChangesSince(repo, branch, since)
 	}
 	if err != nil {
 		return err
 	}
-
-	if *project == "" {
-		var err error
-		if branch.UseUpstream {
-			// reset UseUpstream so we don't try to get the remote for an upstream itself
-			*project, err = findProject(compose.ReleaseBranch{Version: branch.Version}.String())
-		} else {
-			log.Printf("current branch %q has no assicated upstream, assuming upstream remote is \"upstream\" for auto-setting project", branch)
-			*project, err = findProject("")
-		}
-		if err != nil {
-			log.Printf("unable to determine URL for upstream remote (set --project manually): %v", err)
+	if tag, isTag := since.(compose.ReleaseTag); isTag {
+		if err := checkReleaseTag(tag); err != nil {
+			return err
 		}
 	}
+	infoFromPRs(&changes)
 
-	return printLog(branch, logChunk{ChangeLog: changes, since: since})
+	chunk := newLogChunk(changes, since, branch)
+	if tagMode {
+		return tagRelease(branch, chunk)
+	}
+	_, err = printLog(branch, chunk)
+	return err
 }
 
 func main() {
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), `Usage of %[1]s [FLAGS]:
+		fmt.Fprintf(flag.CommandLine.Output(), `Usage of %[1]s [tag] [FLAGS]:
+
+  Prints the release notes for the next release on the current release
+  branch.  With "tag", also creates an annotated tag for that release at the
+  head of the branch, with the notes as its message.
 
   Examples:
 
@@ -118,13 +312,67 @@ func main() {
   # Show docs contributions in the release notes
   %[1]s --show-others docs
 
+  # Accept Conventional Commits titles (feat: ..., fix(scope): ...) as well as emoji
+  %[1]s --convention any
+
+  # Categorize PRs without a title prefix using their kind/* labels
+  GITHUB_TOKEN=... %[1]s --use-labels
+
+  # Treat PRs whose descriptions say "BREAKING CHANGE:" as breaking
+  GITHUB_TOKEN=... %[1]s --promote-breaking
+
+  # Use the release-note blocks from PR descriptions instead of titles
+  GITHUB_TOKEN=... %[1]s --use-release-notes
+
+  # Use plain-text labels instead of GitHub shortcodes (e.g. for emails)
+  %[1]s --style text
+
+  # Find PRs in a repository that uses GitHub's squash merges
+  %[1]s --merge-strategy squash
+
+  # Generate notes for a module in a subdirectory, tagged like tools/setup-envtest/v0.1.0
+  %[1]s --paths tools/setup-envtest --tag-prefix tools/setup-envtest/
+
+  # Generate notes on a project with one release branch per minor release, like release-1.28
+  %[1]s --branch-scheme 'release-{major}.{minor}'
+
+  # Preview the next minor or major release from the main branch, before cutting its release branch
+  %[1]s --branch main
+
+  # Mark fixes that were already cherry-picked into the previous release branch's patch releases
+  %[1]s --shipped annotate
+
+  # Generate notes for a checkout elsewhere, giving up on git after a minute
+  %[1]s --repo ~/src/controller-runtime --git-timeout 1m
+
+  # Read the repository without the git binary (e.g. in a minimal container)
+  %[1]s --git-backend go
+
+  # Use extra PR types (or different section headings) from a file
+  %[1]s --types pr-types.yaml
+
+  # Print the notes, the version, and why it was picked as JSON, for other tools
+  %[1]s --output json
+
+  # Tag & sign the next release with its notes, and push the tag
+  %[1]s tag --sign --push
+
+  # Tag a different version than the computed one, without confirming
+  %[1]s tag --version v0.7.0-rc.1 --yes
+
   Flags:
 
 `, os.Args[0])
 
 		flag.PrintDefaults()
 	}
-	flag.Parse()
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "tag" {
+		tagMode = true
+		args = args[1:]
+	}
+	// errors exit, like with flag.Parse
+	_ = flag.CommandLine.Parse(args)
 
 	err := run()
 	if err != nil {
@@ -136,33 +384,122 @@ func main() {
 // since a given committish.
 type logChunk struct {
 	since git.Committish
+	// until is where these changes end, for linking to comparisons when
+	// since isn't a release.
+	until git.Committish
 	compose.ChangeLog
 }
 
+// newLogChunk prepares the changes on the given branch since the given point
+// for printing.  Comparisons end at the local branch, since the forge doesn't
+// know about upstream references like `release-0.7@{u}`.
+func newLogChunk(changes compose.ChangeLog, since git.Committish, branch compose.ReleaseBranch) logChunk {
+	return logChunk{ChangeLog: changes, since: since, until: branch.Local()}
+}
+
 // Print prints the changes within this chunk along with a header indicating
 // when these changes are from.
 func (c *logChunk) Print() {
-	fmt.Printf("\n**changes since [%[1]s](https://github.com/%[2]s/releases/%[1]s)**\n", c.since.Committish(), *project)
-
-	sectionIfPresent(c.Breaking, ":warning: Breaking Changes")
-	sectionIfPresent(c.Features, ":sparkles: New Features")
-	sectionIfPresent(c.Bugs, ":bug: Bug Fixes")
-
-	optionals := strings.Split(*showOthers, ",")
-	for _, opt := range optionals {
-		switch opt {
-		case "docs":
-			sectionIfPresent(c.Docs, ":book: Documentation")
-		case "infra":
-			sectionIfPresent(c.Infra, ":seedling: Infra & Such")
-		case "":
+	link := projectInfo.CompareURL(c.since.Committish(), c.until.Committish())
+	if _, isRelease := c.since.(compose.ReleaseTag); isRelease {
+		link = projectInfo.ReleaseURL(c.since.Committish())
+	}
+	fmt.Fprintf(out, "\n**changes since [%s](%s)**\n", c.since.Committish(), link)
+
+	taxonomy := common.CurrentTaxonomy()
+	for _, prType := range shownTypes() {
+		entries := c.Entries(prType)
+		if prType == common.UncategorizedPR {
+			entries = withSuggestions(entries)
+		}
+		sectionIfPresent(entries, prType.Render(common.RenderStyle(*renderStyle))+" "+taxonomy.Info(prType).Section)
+	}
+}
+
+// jsonChunk is a logChunk, as printed with --output json.
+type jsonChunk struct {
+	Since    string        `json:"since"`
+	Sections []jsonSection `json:"sections"`
+}
+
+// jsonSection holds the changes of one type, as printed with --output json.
+type jsonSection struct {
+	Type    string             `json:"type"`
+	Heading string             `json:"heading"`
+	Entries []compose.LogEntry `json:"entries"`
+}
+
+// JSON returns the changes within this chunk in the form printed with
+// --output json, with the same sections that Print would print.
+// Uncategorized changes don't get suggestions, since those are just hints
+// for whoever's editing the notes.
+func (c *logChunk) JSON() jsonChunk {
+	res := jsonChunk{Since: c.since.Committish(), Sections: []jsonSection{}}
+	taxonomy := common.CurrentTaxonomy()
+	for _, prType := range shownTypes() {
+		entries := c.Entries(prType)
+		if len(entries) == 0 {
+			continue
+		}
+		info := taxonomy.Info(prType)
+		res.Sections = append(res.Sections, jsonSection{Type: info.Name, Heading: info.Section, Entries: entries})
+	}
+	return res
+}
+
+// shownTypes lists the PR types whose changes get printed, which excludes
+// optional types that weren't requested with --show-others.
+func shownTypes() []common.PRType {
+	taxonomy := common.CurrentTaxonomy()
+	requested := make(map[string]bool)
+	for _, opt := range strings.Split(*showOthers, ",") {
+		if opt == "" {
 			// don't do anything
-		default:
+			continue
+		}
+		if prType, known := taxonomy.ByName(opt); !known || !taxonomy.Info(prType).IsOptional() {
 			log.Printf("unknown optinal section %q, skipping", opt)
+			continue
 		}
+		requested[opt] = true
 	}
 
-	sectionIfPresent(c.Uncategorized, ":question: Sort these by hand")
+	var res []common.PRType
+	for _, prType := range taxonomy.Types() {
+		if taxonomy.Info(prType).IsOptional() && !requested[taxonomy.Info(prType).Name] {
+			continue
+		}
+		res = append(res, prType)
+	}
+	return res
+}
+
+// withSuggestions annotates uncategorized changes with a suggested type
+// (based on their titles and the files they change), to make sorting them by
+// hand a bit easier.
+func withSuggestions(changes []compose.LogEntry) []compose.LogEntry {
+	res := make([]compose.LogEntry, len(changes))
+	for i, change := range changes {
+		res[i] = change
+		var paths []string
+		if change.Commit != "" {
+			var err error
+			if paths, err = repo.ChangedFiles(change.Commit); err != nil {
+				log.Printf("unable to list the files changed by %s, suggesting a type from the title alone: %v", change.Commit, err)
+			}
+		}
+		suggestion, found := common.SuggestType(change.Title, paths)
+		if !found {
+			continue
+		}
+		annotation := fmt.Sprintf(" *(maybe %s %s? %.0f%% sure)*", suggestion.Type.Render(common.RenderStyle(*renderStyle)), suggestion.Type, suggestion.Confidence*100)
+		if res[i].Note != "" {
+			res[i].Note += annotation
+		} else {
+			res[i].Title += annotation
+		}
+	}
+	return res
 }
 
 // release holds the name of the upcoming release, and the intermediate information
@@ -170,38 +507,71 @@ func (c *logChunk) Print() {
 type release struct {
 	compose.ReleaseInfo
 	next compose.ReleaseTag
+	// decision is why the computed version was picked (which may not be
+	// next, if --version was given).
+	decision compose.VersionDecision
 }
 
 // releaseInfo computes compose.ReleaseInfo & the expected next release version
 // given a branch and some changes.
 func releaseInfo(branch compose.ReleaseBranch, changes logChunk) (release, error) {
-	relInfo := compose.ReleaseInfo{Pre10: !*forceV1}
-	switch *relType {
-	case "final":
-		relInfo.Kind = compose.ReleaseFinal
-	case "alpha":
-		relInfo.Kind = compose.ReleaseAlpha
-	case "beta":
-		relInfo.Kind = compose.ReleaseBeta
-	case "rc":
-		relInfo.Kind = compose.ReleaseCandidate
-	default:
-		return release{}, fmt.Errorf("unknown release type %q, must be final|alpha|beta|rc", *relType)
+	kind, err := compose.ParseReleaseKind(*relType)
+	if err != nil {
+		return release{}, err
 	}
-	nextVer, err := changes.ExpectedNextVersion(changes.since, relInfo)
+	relInfo := compose.ReleaseInfo{Kind: kind, Pre10: !*forceV1, NewLine: branch.IsDevelopment()}
+	if *initialVersion != "" {
+		initial, err := parseVersionFlag("initial", *initialVersion, branch.TagPrefix)
+		if err != nil {
+			return release{}, err
+		}
+		if _, released := changes.since.(compose.ReleaseTag); released {
+			log.Printf("ignoring initial version %s, since %s has already been released", initial, changes.since.Committish())
+		} else {
+			relInfo.Initial = &initial
+		}
+	}
+	decision, err := changes.ExplainNextVersion(changes.since, relInfo)
 	if err != nil {
 		return release{}, err
 	}
+	// name the release like the branch's, even when starting from some
+	// commit that isn't a release (see --from)
+	decision.Next.Prefix = branch.TagPrefix
+	fmt.Fprintf(os.Stderr, "\x1b[1;36mversion\x1b[0m %s\n", decision)
+	nextVer := decision.Next
+	if *releaseVersion != "" {
+		chosen, err := parseVersionFlag("release", *releaseVersion, branch.TagPrefix)
+		if err != nil {
+			return release{}, err
+		}
+		if chosen.String() != nextVer.String() {
+			log.Printf("using release version %s instead of the computed version %s", chosen, nextVer)
+			nextVer = chosen
+		}
+	}
 
 	return release{
 		ReleaseInfo: relInfo,
 		next:        nextVer,
+		decision:    decision,
 	}, nil
 }
 
+// parseVersionFlag parses a version given on the command line, with or
+// without the given tag prefix & leading v.
+func parseVersionFlag(what, raw, prefix string) (compose.ReleaseTag, error) {
+	parsed, err := semver.ParseTolerant(strings.TrimPrefix(raw, prefix))
+	if err != nil {
+		return compose.ReleaseTag{}, fmt.Errorf("invalid %s version %q: %w", what, raw, err)
+	}
+	return compose.ReleaseTag{Version: parsed, Prefix: prefix}, nil
+}
+
 // printLog prints the release log with appropriate header, changes-since link(s),
-// and potentially a full extra change-log if we're going from pre-release to final.
-func printLog(branch compose.ReleaseBranch, recentChanges logChunk) error {
+// and potentially a full extra change-log if we're going from pre-release to final,
+// returning the version being released.
+func printLog(branch compose.ReleaseBranch, recentChanges logChunk) (compose.ReleaseTag, error) {
 	if len(recentChanges.Breaking) > 0 {
 		fmt.Fprint(os.Stderr, "\x1b[1;31mbreaking changes this version\x1b[0m\n")
 	}
@@ -211,30 +581,36 @@ func printLog(branch compose.ReleaseBranch, recentChanges logChunk) error {
 
 	rel, err := releaseInfo(branch, recentChanges)
 	if err != nil {
-		return err
+		return compose.ReleaseTag{}, err
 	}
 
 	// if we're going from pre-release to final, print out the total changes
 	var otherChanges *logChunk
 	if *extraInfoOnFinal && compose.IsPreReleaseToFinal(recentChanges.since, rel.next) {
 		// the cast is guaranteed by IsPreReleaseFinal
-		prev, err := compose.ClosestFinal(git.Actual, recentChanges.since.(compose.ReleaseTag))
+		prev, err := compose.ClosestFinal(repo, recentChanges.since.(compose.ReleaseTag))
 		if err != nil {
-			return fmt.Errorf("unable to find last final release (try running with --print-full-final=false if that's expected): %w", err)
+			return compose.ReleaseTag{}, fmt.Errorf("unable to find last final release (try running with --print-full-final=false if that's expected): %w", err)
+		}
+		if err := checkReleaseTag(*prev); err != nil {
+			return compose.ReleaseTag{}, err
 		}
 
-		otherLog, err := compose.ChangesSince(git.Actual, branch, *prev)
+		otherLog, err := compose.ChangesSince(repo, branch, *prev)
 		if err != nil {
-			return fmt.Errorf("unable to compute changes since last final release (try running with --print-full-final=false if that's expected): %w", err)
-		}
-		otherChanges = &logChunk{
-			ChangeLog: otherLog,
-			since:     *prev,
+			return compose.ReleaseTag{}, fmt.Errorf("unable to compute changes since last final release (try running with --print-full-final=false if that's expected): %w", err)
 		}
+		infoFromPRs(&otherLog)
+		otherChunk := newLogChunk(otherLog, *prev, branch)
+		otherChanges = &otherChunk
+	}
+
+	if *outputFormat == "json" {
+		return rel.next, printJSON(rel, recentChanges, otherChanges)
 	}
 
 	// the actual log
-	fmt.Printf("# %s\n", rel.next)
+	fmt.Fprintf(out, "# %s\n", rel.next)
 
 	recentChanges.Print()
 
@@ -242,72 +618,250 @@ func printLog(branch compose.ReleaseBranch, recentChanges logChunk) error {
 		otherChanges.Print()
 	}
 
-	fmt.Println("")
-	fmt.Println("*Thanks to all our contributors!*")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "*Thanks to all our contributors!*")
+
+	return rel.next, nil
+}
+
+// jsonNotes is the release notes, as printed with --output json.
+type jsonNotes struct {
+	// Version is the version being released.
+	Version compose.ReleaseTag `json:"version"`
+	// Decision is why the computed version was picked.  It may differ from
+	// Version, if --version was given.
+	Decision compose.VersionDecision `json:"decision"`
+	// Changes are the changes since the previous release, followed by the
+	// changes since the last final release, when going from pre-release to
+	// final (see --print-full-final).
+	Changes []jsonChunk `json:"changes"`
+}
 
+// printJSON prints the release notes for the given release as JSON (see
+// jsonNotes).  otherChanges may be nil.
+func printJSON(rel release, recentChanges logChunk, otherChanges *logChunk) error {
+	notes := jsonNotes{
+		Version:  rel.next,
+		Decision: rel.decision,
+		Changes:  []jsonChunk{recentChanges.JSON()},
+	}
+	if otherChanges != nil {
+		notes.Changes = append(notes.Changes, otherChanges.JSON())
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(notes); err != nil {
+		return fmt.Errorf("unable to print notes as JSON: %w", err)
+	}
 	return nil
 }
 
-// formatEntry turns out a single log entry into a string form for printing.
+// infoFromPRs categorizes uncategorized changes using PR labels (if
+// --use-labels is set), promotes changes with breaking-change markers (if
+// --promote-breaking is set), and fills in release notes from PR bodies (if
+// --use-release-notes is set).  Failures aren't fatal -- anything left over
+// just needs to be sorted or touched up by hand.
+func infoFromPRs(changes *compose.ChangeLog) {
+	if prInfo == nil {
+		return
+	}
+	if *useLabels {
+		if err := changes.CategorizeFromLabels(prInfo); err != nil {
+			fmt.Fprintf(os.Stderr, "\x1b[1;31munable to categorize changes from labels, continuing on without them\x1b[0m: %v\n", err)
+		}
+	}
+	if *promoteBreaking {
+		if err := changes.PromoteBreaking(prInfo); err != nil {
+			fmt.Fprintf(os.Stderr, "\x1b[1;31munable to check PRs for breaking changes, double-check the version manually\x1b[0m: %v\n", err)
+		}
+	}
+	if *useReleaseNotes {
+		if err := changes.ApplyReleaseNotes(prInfo); err != nil {
+			fmt.Fprintf(os.Stderr, "\x1b[1;31munable to fetch release notes from PRs, continuing on with titles\x1b[0m: %v\n", err)
+		}
+	}
+}
+
+// formatEntry turns out a single log entry into a string form for printing,
+// preferring the release note from the PR over the title.
 func formatEntry(entry compose.LogEntry) string {
-	if entry.PRNumber == "" {
-		return entry.Title
+	text := entry.Title
+	if entry.Note != "" {
+		text = entry.Note
+	}
+	if entry.PRNumber != "" {
+		text = fmt.Sprintf("%s (%s)", text, projectInfo.PullRef(entry.PRNumber))
+	}
+	if entry.AlsoIn != nil {
+		text += fmt.Sprintf(" *(also in %s)*", entry.AlsoIn)
+	}
+	return text
+}
+
+// printEntry prints a single log entry as a list item at the given
+// indentation, indenting any extra lines (from multi-line release notes) to
+// match.
+func printEntry(indent string, entry compose.LogEntry) {
+	lines := strings.Split(formatEntry(entry), "\n")
+	fmt.Fprintf(out, "%s- %s\n", indent, lines[0])
+	for _, line := range lines[1:] {
+		if line == "" {
+			fmt.Fprintln(out, "")
+			continue
+		}
+		fmt.Fprintf(out, "%s  %s\n", indent, line)
 	}
-	return fmt.Sprintf("%s (#%s)", entry.Title, entry.PRNumber)
 }
 
 // sectionIfPresent prints a section with the given title if any changes are
 // present.
 func sectionIfPresent(changes []compose.LogEntry, title string) {
 	if len(changes) > 0 {
-		fmt.Println("")
-		fmt.Printf("## %s\n", title)
-		fmt.Println("")
+		fmt.Fprintln(out, "")
+		fmt.Fprintf(out, "## %s\n", title)
+		fmt.Fprintln(out, "")
+		if *groupByArea {
+			printByArea(changes)
+			return
+		}
 		for _, change := range changes {
-			fmt.Printf("- %s\n", formatEntry(change))
+			printEntry("", change)
+		}
+	}
+}
+
+// printByArea prints changes without an area, followed by the rest of the
+// changes grouped by area (in alphabetical order).
+func printByArea(changes []compose.LogEntry) {
+	byArea := make(map[string][]compose.LogEntry)
+	var areas []string
+	for _, change := range changes {
+		if change.Area == "" {
+			printEntry("", change)
+			continue
+		}
+		if _, seen := byArea[change.Area]; !seen {
+			areas = append(areas, change.Area)
+		}
+		// the area is already in the group heading
+		_, change.Title = common.SplitArea(change.Title)
+		byArea[change.Area] = append(byArea[change.Area], change)
+	}
+
+	sort.Strings(areas)
+	for _, area := range areas {
+		fmt.Fprintf(out, "- **%s**:\n", area)
+		for _, change := range byArea[area] {
+			printEntry("  ", change)
+		}
+	}
+}
+
+// checkReleaseTag checks that the given release tag is annotated & signed by
+// one of the --tag-keys, warning about problems (or failing, with
+// --tag-check=strict).
+func checkReleaseTag(tag compose.ReleaseTag) error {
+	if *tagCheck == "off" {
+		return nil
+	}
+	var policy compose.TagPolicy
+	for _, key := range strings.Split(*tagKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			policy.AllowedKeys = append(policy.AllowedKeys, key)
+		}
+	}
+
+	err := compose.CheckTag(repo, tag, policy)
+	if err == nil || *tagCheck == "strict" {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "\x1b[1;33m%v\x1b[0m (continuing anyway -- use --tag-check=strict to fail instead)\n", err)
+	return nil
+}
+
+// tagRelease prints the release notes, then (once confirmed) tags the head
+// of the local release branch with them, pushing the tag if --push is set.
+func tagRelease(branch compose.ReleaseBranch, changes logChunk) error {
+	var notes bytes.Buffer
+	out = io.MultiWriter(os.Stdout, &notes)
+	version, err := printLog(branch, changes)
+	out = os.Stdout
+	if err != nil {
+		return err
+	}
+
+	if !*assumeYes {
+		fmt.Fprintf(os.Stderr, "\ntag the head of %q as %s with these notes? [y/N] ", *branchName, version)
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("unable to read confirmation: %w", err)
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return fmt.Errorf("not tagging %s (pass --yes to skip confirmation, or --version to pick a different version)", version)
+		}
+	}
+
+	if err := compose.CreateTag(repo, branch, version, notes.String(), *signTag); err != nil {
+		return err
+	}
+	log.Printf("created tag %s", version)
+	if !*pushTag {
+		return nil
+	}
+
+	remote, err := repo.RemoteForUpstreamFor(*branchName)
+	if err != nil {
+		log.Printf("current branch %q has no associated upstream, pushing to \"upstream\" remote", *branchName)
+		remote = "upstream"
+	}
+	if err := repo.PushTag(remote, git.Tag(version.Committish())); err != nil {
+		return err
+	}
+	log.Printf("pushed tag %s to remote %q", version, remote)
+	return nil
+}
+
+// isDevelopmentBranch checks if the given branch is one of the
+// --development-branches.
+func isDevelopmentBranch(branchName string) bool {
+	for _, name := range strings.Split(*devBranches, ",") {
+		if name = strings.TrimSpace(name); name != "" && name == branchName {
+			return true
 		}
 	}
+	return false
 }
 
 // findProject guesses at the project for this repo. If a branch name is
-// specified, it will be extracted from a github remote on the remote for the
-// upstream for that branch.  Otherwise, it'll be extracted from a github
-// remote on the "upstream" remote.
-func findProject(branchName string) (string, error) {
+// specified, it will be extracted from the URL of the remote for the upstream
+// for that branch.  Otherwise, it'll be extracted from the URL of the
+// "upstream" remote.
+func findProject(branchName string) (forge.Project, error) {
 	remote := "upstream"
 	if branchName != "" {
 		var err error
-		remote, err = git.Actual.RemoteForUpstreamFor(branchName)
+		remote, err = repo.RemoteForUpstreamFor(branchName)
 		if err != nil {
-			return "", fmt.Errorf("unable to determine upstream of branch %q: %w", branchName, err)
+			return forge.Project{}, fmt.Errorf("unable to determine upstream of branch %q: %w", branchName, err)
 		}
 		log.Printf("remote for branch %q was %q", branchName, remote)
 	}
 
 	log.Printf("checking upstream URL for remote %q", remote)
-	upstreamURL, err := git.Actual.URLForRemote(remote)
+	upstreamURL, err := repo.URLForRemote(remote)
 	if err != nil {
-		return "", fmt.Errorf("unable to determine upstream URL for %q: %w", remote, err)
-	}
-
-	project := upstreamURL
-
-	project = strings.TrimPrefix(project, "git@github.com:")
-	project = strings.TrimPrefix(project, "https://github.com/")
-	if project == upstreamURL {
-		return "", fmt.Errorf("unrecognized upstream URL format %q (expected either git@github.com:* or https://github.com/*)", upstreamURL)
+		return forge.Project{}, fmt.Errorf("unable to determine upstream URL for %q: %w", remote, err)
 	}
 
-	project = strings.TrimSuffix(project, ".git")
-	return project, nil
+	return forge.ParseURL(upstreamURL)
 }
 
 func refreshUpstream(branchName string) error {
-	remote, err := git.Actual.RemoteForUpstreamFor(branchName)
+	remote, err := repo.RemoteForUpstreamFor(branchName)
 	if err != nil {
-		fmt.Errorf("unable to determine upstream of branch %q: %w", branchName, err)
+		return fmt.Errorf("unable to determine upstream of branch %q: %w", branchName, err)
 	}
-	if err := git.Actual.Fetch(remote); err != nil {
+	if err := repo.Fetch(remote); err != nil {
 		return fmt.Errorf("unable to refresh remote %q: %w", remote, err)
 	}
 	return nil
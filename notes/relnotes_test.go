@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"github.com/blang/semver/v4"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/kubebuilder-release-tools/notes/compose"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/forge"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git"
+	"sigs.k8s.io/kubebuilder-release-tools/notes/git/gittest"
+)
+
+var _ = Describe("Printing changes", func() {
+	var printed *bytes.Buffer
+	BeforeEach(func() {
+		printed = &bytes.Buffer{}
+		out = printed
+		projectInfo = forge.Project{Kind: forge.GitHub, Scheme: "https", Host: "github.com", Owner: "kubernetes-sigs", Name: "controller-runtime"}
+	})
+	AfterEach(func() {
+		out = os.Stdout
+		projectInfo = forge.Project{Kind: forge.GitHub}
+	})
+
+	It("should link to the comparison with the local branch when using upstream branches", func() {
+		branch := compose.ReleaseBranch{Version: semver.Version{Minor: 7}, UseUpstream: true}
+		chunk := newLogChunk(compose.ChangeLog{}, git.SomeCommittish("0123abc"), branch)
+		chunk.Print()
+		Expect(printed.String()).To(ContainSubstring("**changes since [0123abc](https://github.com/kubernetes-sigs/controller-runtime/compare/0123abc...release-0.7)**"))
+	})
+
+	It("should link to the release when starting from one", func() {
+		branch := compose.ReleaseBranch{Version: semver.Version{Minor: 7}, UseUpstream: true}
+		chunk := newLogChunk(compose.ChangeLog{}, compose.ReleaseTag{Version: semver.MustParse("0.6.2")}, branch)
+		chunk.Print()
+		Expect(printed.String()).To(ContainSubstring("**changes since [v0.6.2](https://github.com/kubernetes-sigs/controller-runtime/releases/v0.6.2)**"))
+	})
+
+	It("should print the version, the decision behind it, and the changes as JSON", func() {
+		branch := compose.ReleaseBranch{Version: semver.Version{Minor: 7}}
+		changes := compose.ChangeLog{
+			Features: []compose.LogEntry{{PRNumber: "1160", Title: "Add Foo field", Commit: "0123abc", Author: "Some Contributor", MergedAt: time.Date(2020, 10, 2, 12, 0, 0, 0, time.UTC)}},
+			Docs:     []compose.LogEntry{{PRNumber: "1161", Title: "Document Foo field"}},
+		}
+		since := compose.ReleaseTag{Version: semver.MustParse("0.6.2")}
+		rel := release{
+			next: compose.ReleaseTag{Version: semver.MustParse("0.7.0")},
+			decision: compose.VersionDecision{
+				Current:  "v0.6.2",
+				Next:     compose.ReleaseTag{Version: semver.MustParse("0.7.0")},
+				Rule:     compose.RuleFeatureBump,
+				Triggers: []compose.VersionTrigger{{Type: "feature", PRNumber: "1160", Title: "Add Foo field"}},
+			},
+		}
+
+		Expect(printJSON(rel, newLogChunk(changes, since, branch), nil)).To(Succeed())
+		Expect(printed.String()).To(MatchJSON(`{
+			"version": "v0.7.0",
+			"decision": {
+				"current": "v0.6.2",
+				"next": "v0.7.0",
+				"rule": "feature-bump",
+				"triggers": [{"type": "feature", "pr": "1160", "title": "Add Foo field"}]
+			},
+			"changes": [{
+				"since": "v0.6.2",
+				"sections": [{
+					"type": "feature",
+					"heading": "New Features",
+					"entries": [{
+						"pr": "1160",
+						"title": "Add Foo field",
+						"commit": "0123abc",
+						"author": "Some Contributor",
+						"mergedAt": "2020-10-02T12:00:00Z"
+					}]
+				}]
+			}]
+		}`))
+	})
+})
+
+var _ = Describe("Suggesting types for uncategorized changes", func() {
+	var guide, client git.Commit
+	BeforeEach(func() {
+		testRepo := gittest.NewRepo()
+		main := testRepo.Branch("main")
+		main.Commit("Initial commit")
+		guide = main.Changing("docs/book/src/webhooks.md", "README.md").MergePR(1200, "Update the webhook guide")
+		client = main.Changing("pkg/client/client.go").MergePR(1201, "Update the webhook guide")
+		repo = testRepo
+	})
+	AfterEach(func() {
+		repo = git.Actual
+	})
+
+	It("should take the files changed by each change into account", func() {
+		changes := withSuggestions([]compose.LogEntry{
+			{PRNumber: "1200", Title: "Update the webhook guide", Commit: guide},
+			{PRNumber: "1201", Title: "Update the webhook guide", Commit: client},
+		})
+		Expect(changes[0].Title).To(HavePrefix("Update the webhook guide *(maybe :book: docs?"))
+		Expect(changes[1].Title).To(HavePrefix("Update the webhook guide *(maybe :seedling: infra?"))
+	})
+
+	It("should suggest a type from the title alone if the changed files can't be listed", func() {
+		changes := withSuggestions([]compose.LogEntry{{PRNumber: "1202", Title: "Update the webhook guide", Commit: git.Commit("0123abc")}})
+		Expect(changes[0].Title).To(HavePrefix("Update the webhook guide *(maybe :seedling: infra?"))
+	})
+})
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v32/github"
+
+	notes "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+type prBreakingMarkerError struct {
+	marker string
+	prType notes.PRType
+}
+
+func (e prBreakingMarkerError) Error() string {
+	return "Your PR description says it's a breaking change, but the title doesn't."
+}
+func (e prBreakingMarkerError) Details() string {
+	hint := fmt.Sprintf("start your PR title with %s (%#q) instead", notes.BreakingPR.Render(notes.UnicodeStyle), notes.CurrentTaxonomy().Info(notes.BreakingPR).Shortcode())
+	if notes.CurrentTaxonomy().Convention() == notes.ConventionalCommits {
+		hint = fmt.Sprintf("add a %#q after the type in your PR title (like %#q) instead", "!", "feat!: ")
+	}
+
+	return fmt.Sprintf(`I found %#q in your PR description, but the title marks this as a %s PR.
+
+If this is a breaking change, %s.  Otherwise, remove %#q from the description.
+
+More details can be found at [sigs.k8s.io/kubebuilder-release-tools/VERSIONING.md](https://sigs.k8s.io/kubebuilder-release-tools/VERSIONING.md).`,
+		e.marker, e.prType, hint, e.marker)
+}
+
+// checkBreakingMarker verifies that PRs with an explicit breaking-change
+// marker in their description are marked as breaking in their title.
+func checkBreakingMarker(pr *github.PullRequest) (string, string, error) {
+	marker := notes.BreakingMarker(pr.GetBody())
+	if marker == "" {
+		return "Your PR description has no breaking-change markers", "", nil
+	}
+
+	parsed := notes.ParseTitleAndBody(trimTitle(pr.GetTitle()), pr.GetBody())
+	if notes.CurrentTaxonomy().Info(parsed.Type).Impact != notes.ImpactMajor {
+		return "", "", prBreakingMarkerError{marker: marker, prType: parsed.Type}
+	}
+
+	return fmt.Sprintf("Your PR is marked as breaking in both the title and description (%#q)", marker), "", nil
+}
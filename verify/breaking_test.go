@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_checkBreakingMarker(t *testing.T) {
+	tests := []struct {
+		name    string
+		pr      *github.PullRequest
+		wantErr bool
+	}{
+		{
+			name: "No markers",
+			pr: &github.PullRequest{
+				Title: stringPointer(":sparkles: Add Foo field"),
+				Body:  stringPointer("Fixes #1160"),
+			},
+		},
+		{
+			name: "Breaking PR with marker",
+			pr: &github.PullRequest{
+				Title: stringPointer(":warning: Switch to leases"),
+				Body:  stringPointer("Switches the lock.\n\nBREAKING CHANGE: ConfigMap locks are gone"),
+			},
+		},
+		{
+			name: "Feature PR with marker",
+			pr: &github.PullRequest{
+				Title: stringPointer(":sparkles: Switch to leases"),
+				Body:  stringPointer("Switches the lock.\n\nBREAKING CHANGE: ConfigMap locks are gone"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Bugfix PR with action required section",
+			pr: &github.PullRequest{
+				Title: stringPointer(":bug: Fix the migration"),
+				Body:  stringPointer("## Action required\n\nRe-run the migration."),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Marker in template comment",
+			pr: &github.PullRequest{
+				Title: stringPointer(":bug: Fix the migration"),
+				Body:  stringPointer("<!-- add BREAKING CHANGE: on its own line for breaking changes -->\nFixes #1160"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := checkBreakingMarker(tt.pr); (err != nil) != tt.wantErr {
+				t.Errorf("checkBreakingMarker() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	notes "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+const (
+	// envPRTypesKey is the action input pointing to a file with custom PR
+	// types, relative to the workspace.
+	envPRTypesKey = "INPUT_PR_TYPES"
+	// envTitleConventionKey is the action input selecting how PR types are
+	// marked in titles (emoji, conventional, or any).
+	envTitleConventionKey = "INPUT_TITLE_CONVENTION"
+	// envUseLabelsKey is the action input allowing the PR type to be
+	// determined from labels when the title has no recognized prefix.
+	envUseLabelsKey = "INPUT_USE_LABELS"
+)
+
+// useLabels indicates that PR labels may determine the PR type.
+var useLabels bool
+
+// configure applies the per-repository settings passed as action inputs.
+func configure() error {
+	if typesPath := os.Getenv(envPRTypesKey); typesPath != "" {
+		taxonomy, err := notes.LoadTaxonomy(typesPath)
+		if err != nil {
+			return err
+		}
+		notes.SetTaxonomy(taxonomy)
+	}
+	if convention := os.Getenv(envTitleConventionKey); convention != "" {
+		if err := notes.CurrentTaxonomy().SetConvention(notes.TitleConvention(convention)); err != nil {
+			return err
+		}
+	}
+	if labels := os.Getenv(envUseLabelsKey); labels != "" {
+		var err error
+		useLabels, err = strconv.ParseBool(labels)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for use_labels: %w", labels, err)
+		}
+	}
+	return nil
+}
@@ -18,9 +18,14 @@ package main
 
 import (
 	"sigs.k8s.io/kubebuilder-release-tools/verify/pkg/action"
+	"sigs.k8s.io/kubebuilder-release-tools/verify/pkg/log"
 )
 
 func main() {
+	if err := configure(); err != nil {
+		log.New().Fatalf(1, "invalid configuration: %v", err)
+	}
+
 	action.New(
 		action.NewPlugin(
 			"PR Type",
@@ -32,5 +37,15 @@ func main() {
 			"Issue/PR tag in PR title",
 			checkIssueInTitle,
 		),
+		action.NewPlugin(
+			"PR Release Note",
+			"Release note block in PR body",
+			checkReleaseNote,
+		),
+		action.NewPlugin(
+			"PR Breaking Change",
+			"Breaking-change markers in PR body",
+			checkBreakingMarker,
+		),
 	).Run()
 }
@@ -28,10 +28,12 @@ import (
 )
 
 const (
-	actionOpen   = "opened"
-	actionReopen = "reopened"
-	actionEdit   = "edited"
-	actionSync   = "synchronize"
+	actionOpen    = "opened"
+	actionReopen  = "reopened"
+	actionEdit    = "edited"
+	actionSync    = "synchronize"
+	actionLabel   = "labeled"
+	actionUnlabel = "unlabeled"
 )
 
 // ValidateFunc is the type of the callback that a Plugin will use to validate the PR contents
@@ -70,7 +72,7 @@ func (p plugin) Entrypoint(env *PREnv) error {
 		return p.onOpen(env)
 	case actionReopen:
 		return p.onReopen(env)
-	case actionEdit:
+	case actionEdit, actionLabel, actionUnlabel:
 		return p.onEdit(env)
 	case actionSync:
 		return p.onSync(env)
@@ -119,9 +121,10 @@ func (p plugin) onReopen(env *PREnv) error {
 	return nil
 }
 
-// onEdit handles "edited" actions
+// onEdit handles "edited" actions, as well as "labeled" and "unlabeled" ones
+// (since labels may be used to determine the PR type)
 func (p plugin) onEdit(env *PREnv) error {
-	p.Debugf("%q handler", actionEdit)
+	p.Debugf("%q handler", env.Event.GetAction())
 	// Reset the check run
 	checkRun, err := p.resetCheckRun(env.Client, env.Owner, env.Repo, env.Event.GetPullRequest().GetHead().GetSHA())
 	if err != nil {
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v32/github"
+
+	notes "sigs.k8s.io/kubebuilder-release-tools/notes/common"
+)
+
+type prReleaseNoteError struct {
+	err error
+}
+
+func (e prReleaseNoteError) Error() string {
+	return "Your PR has a malformed release-note block."
+}
+func (e prReleaseNoteError) Details() string {
+	return fmt.Sprintf(`The problem was: %s.
+
+The release-note block is optional, but if present, it must look like
+
+	%s
+	A description of the change for users.
+	%s
+
+Use %#q as the contents to leave this PR out of the release notes entirely.`,
+		e.err, "```release-note", "```", notes.NoReleaseNote)
+}
+
+// checkReleaseNote verifies that the release-note block in the PR body, if
+// any, is well-formed.
+func checkReleaseNote(pr *github.PullRequest) (string, string, error) {
+	note, found, err := notes.ReleaseNoteFromBody(pr.GetBody())
+	switch {
+	case err != nil:
+		return "", "", prReleaseNoteError{err: err}
+	case !found:
+		return "Your PR has no release-note block, so the title will be used", "", nil
+	case note == notes.NoReleaseNote:
+		return "Your PR will be left out of the release notes", "", nil
+	default:
+		return "Your PR has a release note", fmt.Sprintf("Release note:\n\n%s\n", note), nil
+	}
+}
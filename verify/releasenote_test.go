@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_checkReleaseNote(t *testing.T) {
+	tests := []struct {
+		name    string
+		pr      *github.PullRequest
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "No body",
+			pr:   &github.PullRequest{},
+			want: "Your PR has no release-note block, so the title will be used",
+		},
+		{
+			name: "Release note",
+			pr: &github.PullRequest{
+				Body: stringPointer("Fixes #1160\n\n```release-note\nThe webhook server may now be started more than once.\n```"),
+			},
+			want: "Your PR has a release note",
+		},
+		{
+			name: "No release note",
+			pr: &github.PullRequest{
+				Body: stringPointer("```release-note\nNONE\n```"),
+			},
+			want: "Your PR will be left out of the release notes",
+		},
+		{
+			name: "Unterminated block",
+			pr: &github.PullRequest{
+				Body: stringPointer("```release-note\nThe webhook server may now be started more than once."),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty block",
+			pr: &github.PullRequest{
+				Body: stringPointer("```release-note\n```"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := checkReleaseNote(tt.pr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkReleaseNote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("checkReleaseNote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
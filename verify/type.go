@@ -33,41 +33,146 @@ var tagRegex = regexp.MustCompile(`^\[[\w-\.]*\]`)
 
 type prTitleTypeError struct {
 	title string
+	// useLabels indicates that labels could've been used instead of a prefix
+	useLabels bool
 }
 
 func (e prTitleTypeError) Error() string {
 	return "No matching PR type indicator found in title."
 }
 func (e prTitleTypeError) Details() string {
+	taxonomy := notes.CurrentTaxonomy()
+	var prefixes strings.Builder
+	for _, prType := range taxonomy.Types() {
+		info := taxonomy.Info(prType)
+		if prType == notes.UncategorizedPR || info.Description == "" {
+			continue
+		}
+		fmt.Fprintf(&prefixes, "- %s: %s (%#q)\n", info.Description, prType.Render(notes.UnicodeStyle), info.Shortcode())
+	}
+
+	// the check only sees the PR itself, not the files it changes, so the
+	// suggestion can only go off of the title
+	var suggestionHint string
+	if suggestion, found := taxonomy.SuggestType(e.title, nil); found {
+		suggestionHint = fmt.Sprintf("\nBased on the title alone (the files changed by the PR weren't considered), this looks like a %s PR (%.0f%% sure), so maybe try:\n\n\t%s\n", suggestion.Type, suggestion.Confidence*100, suggestion.Title)
+	}
+
+	var labelsHint string
+	if e.useLabels {
+		labelsHint = fmt.Sprintf("\nAlternatively, you can add one of these labels to the PR:\n\n%s", typeLabels(taxonomy))
+	}
+
+	switch taxonomy.Convention() {
+	case notes.ConventionalCommits:
+		return fmt.Sprintf(
+			`I saw a title of %#q, which doesn't seem to have a recognized [Conventional Commits](https://www.conventionalcommits.org) header.
+
+You need to start your PR title with one of these types, optionally followed by a scope (like %#q) and a %#q for breaking changes:
+
+%s%s%s
+More details can be found at [sigs.k8s.io/kubebuilder-release-tools/VERSIONING.md](https://sigs.k8s.io/kubebuilder-release-tools/VERSIONING.md).`,
+			e.title, "fix(client): ", "!", conventionalTypes(taxonomy), labelsHint, suggestionHint)
+	case notes.AnyConvention:
+		fmt.Fprintf(&prefixes, "\nAlternatively, you can use a [Conventional Commits](https://www.conventionalcommits.org) header (like %#q) with one of these types:\n\n%s", "fix(client): ", conventionalTypes(taxonomy))
+	}
+
 	return fmt.Sprintf(
 		`I saw a title of %#q, which doesn't seem to have any of the acceptable prefixes.
 
 You need to have one of these as the prefix of your PR title:
 
-- Breaking change: ⚠ (%#q)
-- Non-breaking feature: ✨ (%#q)
-- Patch fix: 🐛 (%#q)
-- Docs: 📖 (%#q)
-- Release: 🚀 (%#q)
-- Infra/Tests/Other: 🌱 (%#q)
-
+%s%s%s
 More details can be found at [sigs.k8s.io/kubebuilder-release-tools/VERSIONING.md](https://sigs.k8s.io/kubebuilder-release-tools/VERSIONING.md).`,
-		e.title, ":warning:", ":sparkles:", ":bug:", ":book:", ":rocket:", ":seedling:")
+		e.title, prefixes.String(), labelsHint, suggestionHint)
+}
+
+// conventionalTypes lists the Conventional Commits types accepted for each PR
+// type in the taxonomy.
+func conventionalTypes(taxonomy *notes.Taxonomy) string {
+	var res strings.Builder
+	for _, prType := range taxonomy.Types() {
+		info := taxonomy.Info(prType)
+		if len(info.Conventional) == 0 {
+			continue
+		}
+		quoted := make([]string, len(info.Conventional))
+		for i, headerType := range info.Conventional {
+			quoted[i] = fmt.Sprintf("%#q", headerType+":")
+		}
+		fmt.Fprintf(&res, "- %s: %s\n", info.Description, strings.Join(quoted, ", "))
+	}
+	return res.String()
+}
+
+// typeLabels lists the labels that indicate each PR type in the taxonomy.
+func typeLabels(taxonomy *notes.Taxonomy) string {
+	var res strings.Builder
+	for _, prType := range taxonomy.Types() {
+		info := taxonomy.Info(prType)
+		if len(info.Labels) == 0 {
+			continue
+		}
+		quoted := make([]string, len(info.Labels))
+		for i, label := range info.Labels {
+			quoted[i] = fmt.Sprintf("%#q", label)
+		}
+		fmt.Fprintf(&res, "- %s: %s\n", info.Description, strings.Join(quoted, ", "))
+	}
+	return res.String()
+}
+
+// labelNames returns the names of the labels on the PR.
+func labelNames(pr *github.PullRequest) []string {
+	names := make([]string, len(pr.Labels))
+	for i, label := range pr.Labels {
+		names[i] = label.GetName()
+	}
+	return names
 }
 
 // verifyPRType checks that the PR title contains a prefix that defines its type
 func verifyPRType(pr *github.PullRequest) (string, string, error) {
 	title := trimTitle(pr.GetTitle())
 
-	prType, finalTitle := notes.PRTypeFromTitle(title)
-	if prType == notes.UncategorizedPR {
-		return "", "", prTitleTypeError{title: title}
+	parsed := notes.ParseTitleAndBody(title, pr.GetBody())
+	if parsed.Type == notes.UncategorizedPR {
+		if useLabels {
+			if prType, label, found := notes.PRTypeFromLabels(labelNames(pr)); found {
+				return fmt.Sprintf("Found %s PR (%s) from label %#q", prType.Render(notes.UnicodeStyle), prType, label), "", nil
+			}
+		}
+		return "", "", prTitleTypeError{title: title, useLabels: useLabels}
 	}
 
-	return fmt.Sprintf("Found %s PR (%s)", prType.Emoji(), prType), fmt.Sprintf(`Final title:
+	return fmt.Sprintf("Found %s PR (%s)", parsed.Type.Render(notes.UnicodeStyle), parsed.Type), parsedTitleDetails(parsed), nil
+}
+
+// parsedTitleDetails describes how the title was parsed, including anything
+// the author may want to fix.
+func parsedTitleDetails(parsed notes.ParsedTitle) string {
+	var details strings.Builder
+	fmt.Fprintf(&details, `Final title:
 
 	%s
-`, finalTitle), nil
+
+Matched prefix %#q`, parsed.Title, strings.TrimSpace(parsed.Prefix))
+	if parsed.Deprecated {
+		fmt.Fprintf(&details, " (deprecated, use %#q instead)", notes.CurrentTaxonomy().Info(parsed.Type).Shortcode())
+	}
+	details.WriteString(".\n")
+
+	if parsed.Area != "" {
+		fmt.Fprintf(&details, "\nArea: %#q\n", parsed.Area)
+	}
+
+	if len(parsed.Warnings) > 0 {
+		details.WriteString("\nWarnings:\n\n")
+		for _, warning := range parsed.Warnings {
+			fmt.Fprintf(&details, "- %s\n", warning)
+		}
+	}
+	return details.String()
 }
 
 func trimTitle(title string) string {
@@ -17,8 +17,12 @@ limitations under the License.
 package main
 
 import (
-	"github.com/google/go-github/v32/github"
+	"strings"
 	"testing"
+
+	"github.com/google/go-github/v32/github"
+
+	notes "sigs.k8s.io/kubebuilder-release-tools/notes/common"
 )
 
 func stringPointer(s string) *string {
@@ -56,6 +60,195 @@ func Test_verifyPRType(t *testing.T) {
 	}
 }
 
+func Test_verifyPRType_conventional(t *testing.T) {
+	if err := notes.CurrentTaxonomy().SetConvention(notes.AnyConvention); err != nil {
+		t.Fatalf("unable to set convention: %v", err)
+	}
+	defer notes.SetTaxonomy(nil)
+
+	tests := []struct {
+		name string
+		pr   *github.PullRequest
+		want string
+	}{
+		{
+			name: "Conventional bugfix PR",
+			pr: &github.PullRequest{
+				Title: stringPointer("fix(webhook): Fixing bug"),
+			},
+			want: "Found 🐛 PR (bugfix)",
+		},
+		{
+			name: "Conventional PR with breaking footer",
+			pr: &github.PullRequest{
+				Title: stringPointer("feat: Switch to leases"),
+				Body:  stringPointer("Switches the lock.\n\nBREAKING CHANGE: ConfigMap locks are gone"),
+			},
+			want: "Found ⚠ PR (breaking)",
+		},
+		{
+			name: "Emoji PR",
+			pr: &github.PullRequest{
+				Title: stringPointer(":book: Fix typo"),
+			},
+			want: "Found 📖 PR (docs)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _, _ := verifyPRType(tt.pr); got != tt.want {
+				t.Errorf("verifyPRType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_verifyPRType_labels(t *testing.T) {
+	useLabels = true
+	defer func() { useLabels = false }()
+
+	tests := []struct {
+		name    string
+		pr      *github.PullRequest
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Bugfix label",
+			pr: &github.PullRequest{
+				Title:  stringPointer("Fixing bug"),
+				Labels: []*github.Label{{Name: stringPointer("lgtm")}, {Name: stringPointer("kind/bug")}},
+			},
+			want: "Found 🐛 PR (bugfix) from label `kind/bug`",
+		},
+		{
+			name: "Prefix beats label",
+			pr: &github.PullRequest{
+				Title:  stringPointer(":book: Fixing typo"),
+				Labels: []*github.Label{{Name: stringPointer("kind/bug")}},
+			},
+			want: "Found 📖 PR (docs)",
+		},
+		{
+			name: "No known labels",
+			pr: &github.PullRequest{
+				Title:  stringPointer("Fixing bug"),
+				Labels: []*github.Label{{Name: stringPointer("lgtm")}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := verifyPRType(tt.pr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyPRType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("verifyPRType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_verifyPRType_details(t *testing.T) {
+	tests := []struct {
+		name string
+		pr   *github.PullRequest
+		want []string
+	}{
+		{
+			name: "Plain PR",
+			pr: &github.PullRequest{
+				Title: stringPointer(":bug: Fixing bug"),
+			},
+			want: []string{"\tFixing bug\n", "Matched prefix `:bug:`."},
+		},
+		{
+			name: "Deprecated prefix",
+			pr: &github.PullRequest{
+				Title: stringPointer("🏃 Run faster"),
+			},
+			want: []string{"Matched prefix `🏃` (deprecated, use `:seedling:` instead)."},
+		},
+		{
+			name: "PR with area",
+			pr: &github.PullRequest{
+				Title: stringPointer(":sparkles: [webhook] Add defaulting"),
+			},
+			want: []string{"\t[webhook] Add defaulting\n", "Area: `webhook`"},
+		},
+		{
+			name: "PR with variation selector",
+			pr: &github.PullRequest{
+				Title: stringPointer("✨\uFE0F Truly sparkly"),
+			},
+			want: []string{"Warnings:", "- removed invisible character U+FE0F from the prefix"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := verifyPRType(tt.pr)
+			if err != nil {
+				t.Fatalf("verifyPRType() returned unexpected error %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("verifyPRType() details = %v, want it to contain %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_prTitleTypeError_Details(t *testing.T) {
+	defaultWant := "- Breaking change: ⚠ (`:warning:`)\n" +
+		"- Non-breaking feature: ✨ (`:sparkles:`)\n" +
+		"- Patch fix: 🐛 (`:bug:`)\n" +
+		"- Docs: 📖 (`:book:`)\n" +
+		"- Release: 🚀 (`:rocket:`)\n" +
+		"- Infra/Tests/Other: 🌱 (`:seedling:`)\n"
+	if got := (prTitleTypeError{title: "blah"}).Details(); !strings.Contains(got, defaultWant) {
+		t.Errorf("prTitleTypeError.Details() = %v, want it to contain %v", got, defaultWant)
+	}
+
+	taxonomy, err := notes.NewTaxonomy(notes.TypeInfo{
+		Name:        "security",
+		Description: "Security fix",
+		Emoji:       "🔒",
+		Shortcodes:  []string{":lock:"},
+		Order:       15,
+	})
+	if err != nil {
+		t.Fatalf("unable to construct taxonomy: %v", err)
+	}
+	notes.SetTaxonomy(taxonomy)
+	defer notes.SetTaxonomy(nil)
+
+	customWant := "- Breaking change: ⚠ (`:warning:`)\n" +
+		"- Security fix: 🔒 (`:lock:`)\n" +
+		"- Non-breaking feature: ✨ (`:sparkles:`)\n"
+	if got := (prTitleTypeError{title: "blah"}).Details(); !strings.Contains(got, customWant) {
+		t.Errorf("prTitleTypeError.Details() = %v, want it to contain %v", got, customWant)
+	}
+}
+
+func Test_prTitleTypeError_Details_suggestion(t *testing.T) {
+	got := (prTitleTypeError{title: "Fix goroutine leak"}).Details()
+	for _, want := range []string{"\t:bug: Fix goroutine leak\n", "Based on the title alone"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("prTitleTypeError.Details() = %v, want it to contain %v", got, want)
+		}
+	}
+
+	if got := (prTitleTypeError{title: "Controller.Watch() should not store watches"}).Details(); strings.Contains(got, "maybe try") {
+		t.Errorf("prTitleTypeError.Details() = %v, want no suggestion", got)
+	}
+}
+
 func Test_trimTitle(t *testing.T) {
 	tests := []struct {
 		name  string